@@ -0,0 +1,46 @@
+// Package action describes the buttons sent with a notification.
+package action
+
+import "strings"
+
+// Style is the color of a button, platforms without styles ignore it.
+type Style string
+
+const (
+	StyleDefault Style = ""
+	StylePrimary Style = "primary"
+	StyleDanger  Style = "danger"
+)
+
+// Action is a button below a message. Clicking it calls back with ID and
+// Value, see the callback package, or opens URL when it is set. IDs can not
+// contain colons.
+type Action struct {
+	ID    string
+	Label string
+	Value string
+	URL   string
+	Style Style
+}
+
+// IsLink reports whether the button opens a page rather than calling back.
+func (a Action) IsLink() bool {
+	return a.URL != ""
+}
+
+// Data packs ID and Value into the single string that Discord and Telegram
+// pass back on a click.
+func (a Action) Data() string {
+	if a.Value == "" {
+		return a.ID
+	}
+	return a.ID + ":" + a.Value
+}
+
+// ParseData splits a string built by Data.
+func ParseData(data string) (id, value string) {
+	if i := strings.IndexByte(data, ':'); i >= 0 {
+		return data[:i], data[i+1:]
+	}
+	return data, ""
+}
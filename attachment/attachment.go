@@ -0,0 +1,94 @@
+// Package attachment holds the files sent along with a notification.
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment is a file sent with a message, its content is either Data or
+// read once from Reader.
+type Attachment struct {
+	Name string
+	// ContentType is the MIME type, it is guessed from Name and the content
+	// when empty.
+	ContentType string
+	Data        []byte
+	Reader      io.Reader
+}
+
+// New returns an attachment holding data.
+func New(name, contentType string, data []byte) *Attachment {
+	return &Attachment{Name: name, ContentType: contentType, Data: data}
+}
+
+// FromReader returns an attachment read from r when it is first sent.
+func FromReader(name, contentType string, r io.Reader) *Attachment {
+	return &Attachment{Name: name, ContentType: contentType, Reader: r}
+}
+
+// Bytes returns the content, reading Reader to the end the first time.
+func (a *Attachment) Bytes() ([]byte, error) {
+	if a.Reader != nil {
+		data, err := ioutil.ReadAll(a.Reader)
+		if err != nil {
+			return nil, err
+		}
+		a.Data, a.Reader = data, nil
+	}
+	if len(a.Data) == 0 {
+		return nil, errors.New("empty attachment " + a.Name)
+	}
+	return a.Data, nil
+}
+
+// Type returns the MIME type of the attachment.
+func (a *Attachment) Type() string {
+	if a.ContentType != "" {
+		return a.ContentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Name)); t != "" {
+		return t
+	}
+	if data, err := a.Bytes(); err == nil {
+		return http.DetectContentType(data)
+	}
+	return "application/octet-stream"
+}
+
+// IsImage reports whether the attachment is an image.
+func (a *Attachment) IsImage() bool {
+	return strings.HasPrefix(a.Type(), "image/")
+}
+
+// FileName returns Name, or a generic name for unnamed attachments.
+func (a *Attachment) FileName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	if exts, _ := mime.ExtensionsByType(a.Type()); len(exts) > 0 {
+		return "attachment" + exts[0]
+	}
+	return "attachment"
+}
+
+// Size returns the size of the content in bytes.
+func (a *Attachment) Size() (int, error) {
+	data, err := a.Bytes()
+	return len(data), err
+}
+
+// NewReader returns a reader over the content.
+func (a *Attachment) NewReader() (io.Reader, error) {
+	data, err := a.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
@@ -0,0 +1,81 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestAttachment_Type(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	tests := []struct {
+		a     *Attachment
+		want  string
+		image bool
+	}{
+		{New("report.csv", "", []byte("a,b\n1,2\n")), "text/csv", false},
+		{New("chart", "", png), "image/png", true},
+		{FromReader("", "", bytes.NewReader(png)), "image/png", true},
+		{New("trace.log", "text/plain", []byte("panic")), "text/plain", false},
+	}
+	for _, tt := range tests {
+		if got, _, _ := mime.ParseMediaType(tt.a.Type()); got != tt.want {
+			t.Errorf("Type(%q) = %q, want %q", tt.a.Name, got, tt.want)
+		}
+		if tt.a.IsImage() != tt.image {
+			t.Errorf("IsImage(%q) = %v", tt.a.Name, !tt.image)
+		}
+	}
+
+	if _, err := New("empty.txt", "", nil).Bytes(); err == nil {
+		t.Error("expected an error for an empty attachment")
+	}
+}
+
+func TestMIME(t *testing.T) {
+	files := []*Attachment{
+		New("report.csv", "", []byte("a,b\n1,2\n")),
+		New("screenshot.png", "image/png", []byte("not really a png")),
+	}
+	raw, err := MIME([]string{"To: ops@example.com", "Subject: disk full"}, "text/html", "<b>db-1</b> is full", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := msg.Header.Get("Subject"); got != "disk full" {
+		t.Errorf("Subject = %q", got)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	var names []string
+	for {
+		p, err := r.NextPart()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if p.FileName() == "" {
+			if !strings.HasPrefix(p.Header.Get("Content-Type"), "text/html") || string(data) != "<b>db-1</b> is full" {
+				t.Errorf("unexpected body part %q: %q", p.Header.Get("Content-Type"), data)
+			}
+			continue
+		}
+		names = append(names, p.FileName())
+	}
+	if strings.Join(names, ",") != "report.csv,screenshot.png" {
+		t.Errorf("attachments = %v", names)
+	}
+}
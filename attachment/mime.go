@@ -0,0 +1,75 @@
+package attachment
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+)
+
+// MIME builds an email with body as its text part, of type bodyType, and
+// files as the following parts. headers come first, in order, as
+// "Name: value" lines.
+func MIME(headers []string, bodyType, body string, files []*Attachment) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, h := range headers {
+		buf.WriteString(h + "\r\n")
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(files) == 0 {
+		buf.WriteString("Content-Type: " + bodyType + "; charset=UTF-8\r\n")
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64(&buf, []byte(body))
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	buf.WriteString("Content-Type: multipart/mixed; boundary=" + w.Boundary() + "\r\n\r\n")
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {bodyType + "; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, []byte(body))
+
+	for _, f := range files {
+		data, err := f.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		name := mime.QEncoding.Encode("UTF-8", f.FileName())
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", f.Type(), name)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", name)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, data)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	s := base64.StdEncoding.EncodeToString(data)
+	var lines []string
+	for len(s) > 76 {
+		lines = append(lines, s[:76])
+		s = s[76:]
+	}
+	lines = append(lines, s)
+	w.Write([]byte(strings.Join(lines, "\r\n") + "\r\n"))
+}
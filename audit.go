@@ -0,0 +1,156 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/redact"
+)
+
+// Outcomes of a delivery attempt.
+const (
+	OutcomeSent   = "sent"
+	OutcomeFailed = "failed"
+)
+
+// AuditRecord is one delivery attempt. It holds no message content or
+// credentials, only a hash of the redacted text.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	MessageID string    `json:"message_id"`
+	// AlertKey is the ThreadKey of the message.
+	AlertKey string   `json:"alert_key,omitempty"`
+	Platform Platform `json:"platform"`
+	// Target is who was notified: the channel, chat or email address, it
+	// is empty for platforms addressed by a secret url, see TargetHash.
+	Target     string `json:"target,omitempty"`
+	TargetHash string `json:"target_hash,omitempty"`
+	// Attempt counts from 1, retrying middlewares add attempts.
+	Attempt    int           `json:"attempt"`
+	Outcome    string        `json:"outcome"`
+	ErrorClass string        `json:"error_class,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	ReceiptIDs []string      `json:"receipt_ids,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	// ContentHash is the sha256 of the redacted text sent.
+	ContentHash string `json:"content_hash"`
+}
+
+// AuditSink records every delivery attempt, see OpenAuditLog and
+// SQLAuditSink.
+type AuditSink interface {
+	Record(ctx context.Context, rec *AuditRecord) error
+}
+
+// WithAuditSink records every delivery attempt to s. Failures to record
+// are logged, they do not fail the delivery.
+func WithAuditSink(s AuditSink) Option {
+	return func(n *Notify) {
+		n.audit = s
+	}
+}
+
+// attemptKey holds the attempt counter of a message, shared by retries.
+type attemptKey struct{}
+
+// newMessageID returns a random id for messages without one.
+func newMessageID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// nextAttempt returns the number of the attempt starting in ctx.
+func nextAttempt(ctx context.Context) int {
+	if n, ok := ctx.Value(attemptKey{}).(*int32); ok {
+		return int(atomic.AddInt32(n, 1))
+	}
+	return 1
+}
+
+// auditTarget returns the target of c that can be recorded as it is.
+func (c *Config) auditTarget() string {
+	switch c.Platform {
+	case PlatformSlack:
+		return c.Channel
+	case PlatformEmail, PlatformSes:
+		// the email platforms take the address as Token
+		return c.Token
+	case PlatformTelegram:
+		if c.ChannelType == NotifyChannelTypeBot {
+			ids := make([]string, len(c.ChatIDs))
+			for i, id := range c.ChatIDs {
+				ids[i] = strconv.FormatInt(id, 10)
+			}
+			return strings.Join(ids, ",")
+		}
+		return c.Channel
+	}
+	return ""
+}
+
+// errorClass sorts delivery errors for audits and alerting on them.
+func errorClass(err error) string {
+	if err == nil {
+		return ""
+	}
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return "timeout"
+		}
+		return "network"
+	case strings.HasPrefix(err.Error(), "missing "):
+		return "config"
+	}
+	return "platform"
+}
+
+// record passes the attempt to the audit sink, if any.
+func (n *Notify) record(ctx context.Context, cfg *Config, m *Message, text string, start time.Time, r *Receipt, err error) {
+	if n.audit == nil {
+		return
+	}
+	secrets := redact.New(cfg.secrets()...)
+	sum := sha256.Sum256([]byte(secrets.String(text)))
+	rec := &AuditRecord{
+		Time:        start,
+		MessageID:   m.ID,
+		AlertKey:    m.ThreadKey,
+		Platform:    cfg.Platform,
+		Target:      cfg.auditTarget(),
+		TargetHash:  cfg.targetHash(),
+		Attempt:     logger.AttemptFrom(ctx),
+		Outcome:     OutcomeSent,
+		Duration:    time.Since(start),
+		ContentHash: hex.EncodeToString(sum[:]),
+	}
+	if err != nil {
+		rec.Outcome = OutcomeFailed
+		rec.ErrorClass = errorClass(err)
+		rec.Error = err.Error()
+	}
+	if r != nil {
+		rec.ReceiptIDs = r.MessageIDs
+	}
+	if err := n.audit.Record(ctx, rec); err != nil {
+		logger.Default(cfg.Logger).Warn("notify audit record failed",
+			logger.Platform(string(cfg.Platform)),
+			logger.Err(err),
+		)
+	}
+}
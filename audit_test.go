@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/slack"
+)
+
+func TestAuditLog(t *testing.T) {
+	log, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+
+	retry := func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			if err := next.Send(ctx, msg); err == nil {
+				return nil
+			}
+			return next.Send(ctx, msg)
+		})
+	}
+	// no token, so both attempts fail before reaching slack
+	n := NewNotify(&Config{Platform: PlatformSlack, Channel: "#ops"}, WithAuditSink(log), WithMiddleware(retry))
+	ctx := context.Background()
+	n.SendMessage(ctx, &Message{Text: "disk full", ThreadKey: "disk"})
+	n.SendMessage(ctx, &Message{Text: "cpu high", ThreadKey: "cpu"})
+
+	recs, err := log.Deliveries(ctx, "disk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("%d records, want 2", len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Attempt != i+1 || rec.MessageID != recs[0].MessageID || rec.MessageID == "" {
+			t.Errorf("record %d: attempt %d of %q", i, rec.Attempt, rec.MessageID)
+		}
+		if rec.Outcome != OutcomeFailed || rec.ErrorClass != "config" || rec.Target != "#ops" {
+			t.Errorf("record %d: %+v", i, rec)
+		}
+		if rec.ContentHash == "" {
+			t.Errorf("record %d has no content hash", i)
+		}
+	}
+}
+
+type auditRecords []*AuditRecord
+
+func (r *auditRecords) Record(ctx context.Context, rec *AuditRecord) error {
+	*r = append(*r, rec)
+	return nil
+}
+
+func TestAuditContentHash(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Attachments []struct {
+				Text string `json:"text"`
+			} `json:"attachments"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Attachments) > 0 {
+			sent = body.Attachments[0].Text
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	defer func(prefix string) { slack.WebhookPrefix = prefix }(slack.WebhookPrefix)
+	slack.WebhookPrefix = srv.URL
+
+	var recs auditRecords
+	n := NewNotify(&Config{Platform: PlatformSlack, Token: srv.URL + "/services/T0/B0/secret"}, WithAuditSink(&recs))
+	if err := n.SendMessage(context.Background(), &Message{Text: "disk full", Severity: SeverityCritical}); err != nil {
+		t.Fatal(err)
+	}
+	// the hash is of the text with the severity emoji slack got
+	sum := sha256.Sum256([]byte(sent))
+	if len(recs) != 1 || sent == "" || sent == "disk full" || recs[0].ContentHash != hex.EncodeToString(sum[:]) {
+		t.Errorf("sent %q, recorded %+v", sent, recs)
+	}
+}
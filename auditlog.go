@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditLog is an AuditSink appending records to a file as JSON lines.
+type AuditLog struct {
+	path string
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// OpenAuditLog opens the JSON lines file at path for appending, creating
+// it if needed.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditLog{path: path, f: f}, nil
+}
+
+func (l *AuditLog) Record(ctx context.Context, rec *AuditRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.f.Write(append(b, '\n'))
+	return err
+}
+
+// Deliveries returns the records of the messages with ThreadKey alertKey,
+// oldest first.
+func (l *AuditLog) Deliveries(ctx context.Context, alertKey string) ([]AuditRecord, error) {
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var recs []AuditRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(nil, 1024*1024)
+	for sc.Scan() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("audit log %s: %w", l.path, err)
+		}
+		if rec.AlertKey == alertKey {
+			recs = append(recs, rec)
+		}
+	}
+	return recs, sc.Err()
+}
+
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+// DefaultAuditTable is the table of SQLAuditSink unless set.
+const DefaultAuditTable = "notify_audit"
+
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// SQLAuditSink is an AuditSink inserting records into a database/sql
+// table, see CreateTable for its columns.
+type SQLAuditSink struct {
+	DB    *sql.DB
+	Table string
+	// Postgres uses $1 placeholders instead of ?.
+	Postgres bool
+}
+
+const auditColumns = "attempted_at, message_id, alert_key, platform, target, target_hash, attempt, outcome, error_class, error_message, receipt_ids, duration_ns, content_hash"
+
+func (s *SQLAuditSink) table() (string, error) {
+	table := s.Table
+	if table == "" {
+		table = DefaultAuditTable
+	}
+	if !tableName.MatchString(table) {
+		return "", fmt.Errorf("invalid audit table name %q", table)
+	}
+	return table, nil
+}
+
+func (s *SQLAuditSink) placeholder(i int) string {
+	if s.Postgres {
+		return "$" + strconv.Itoa(i)
+	}
+	return "?"
+}
+
+// CreateTable creates the audit table if it does not exist.
+func (s *SQLAuditSink) CreateTable(ctx context.Context) error {
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+table+` (
+	attempted_at TIMESTAMP NOT NULL,
+	message_id VARCHAR(64) NOT NULL,
+	alert_key VARCHAR(255) NOT NULL,
+	platform VARCHAR(32) NOT NULL,
+	target VARCHAR(255) NOT NULL,
+	target_hash VARCHAR(32) NOT NULL,
+	attempt INTEGER NOT NULL,
+	outcome VARCHAR(16) NOT NULL,
+	error_class VARCHAR(32) NOT NULL,
+	error_message TEXT NOT NULL,
+	receipt_ids TEXT NOT NULL,
+	duration_ns BIGINT NOT NULL,
+	content_hash VARCHAR(64) NOT NULL
+)`)
+	return err
+}
+
+func (s *SQLAuditSink) Record(ctx context.Context, rec *AuditRecord) error {
+	table, err := s.table()
+	if err != nil {
+		return err
+	}
+	ids, err := json.Marshal(rec.ReceiptIDs)
+	if err != nil {
+		return err
+	}
+	placeholders := make([]string, 13)
+	for i := range placeholders {
+		placeholders[i] = s.placeholder(i + 1)
+	}
+	_, err = s.DB.ExecContext(ctx,
+		`INSERT INTO `+table+` (`+auditColumns+`) VALUES (`+strings.Join(placeholders, ", ")+`)`,
+		rec.Time.UTC(), rec.MessageID, rec.AlertKey, string(rec.Platform), rec.Target, rec.TargetHash,
+		rec.Attempt, rec.Outcome, rec.ErrorClass, rec.Error, string(ids), int64(rec.Duration), rec.ContentHash,
+	)
+	return err
+}
+
+// Deliveries returns the records of the messages with ThreadKey alertKey,
+// oldest first.
+func (s *SQLAuditSink) Deliveries(ctx context.Context, alertKey string) ([]AuditRecord, error) {
+	table, err := s.table()
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.DB.QueryContext(ctx,
+		`SELECT `+auditColumns+` FROM `+table+` WHERE alert_key = `+s.placeholder(1)+` ORDER BY attempted_at`,
+		alertKey,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []AuditRecord
+	for rows.Next() {
+		var (
+			rec      AuditRecord
+			platform string
+			ids      string
+			duration int64
+		)
+		err := rows.Scan(&rec.Time, &rec.MessageID, &rec.AlertKey, &platform, &rec.Target, &rec.TargetHash,
+			&rec.Attempt, &rec.Outcome, &rec.ErrorClass, &rec.Error, &ids, &duration, &rec.ContentHash)
+		if err != nil {
+			return nil, err
+		}
+		rec.Platform = Platform(platform)
+		rec.Duration = time.Duration(duration)
+		if err := json.Unmarshal([]byte(ids), &rec.ReceiptIDs); err != nil {
+			return nil, err
+		}
+		recs = append(recs, rec)
+	}
+	return recs, rows.Err()
+}
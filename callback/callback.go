@@ -0,0 +1,189 @@
+// Package callback receives the clicks on action buttons, verifies that they
+// come from the platform and dispatches them to handlers registered by
+// action id.
+//
+// Mount the handler under a path ending with the platform, for example
+//
+//	h := callback.New(callback.Options{SlackSigningSecret: secret})
+//	h.Handle("ack", func(ctx context.Context, c *callback.Click) (string, error) {
+//		return "Acknowledged", ack(c.Value)
+//	})
+//	http.Handle("/callbacks/", h) // receives /callbacks/slack
+package callback
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/discord"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/slack"
+)
+
+// Platforms, as the last element of the callback path and in Click.
+const (
+	PlatformSlack    = "slack"
+	PlatformDiscord  = "discord"
+	PlatformTelegram = "telegram"
+	PlatformLark     = "lark"
+	PlatformDingTalk = "dingtalk"
+)
+
+// maxBody is the largest callback request read.
+const maxBody = 1 << 20
+
+// Click is a click on an action button.
+type Click struct {
+	Platform string
+	ActionID string
+	Value    string
+
+	// User, Channel and MessageID are the platform's ids for who clicked
+	// and where, when it reports them.
+	User      string
+	Channel   string
+	MessageID string
+
+	// Payload is the request body, the interaction payload for Slack or
+	// the query for DingTalk.
+	Payload []byte
+}
+
+// HandlerFunc handles a click. reply is shown to the user who clicked on
+// the platforms that support it.
+type HandlerFunc func(ctx context.Context, click *Click) (reply string, err error)
+
+// Options holds the credentials used to verify each platform's callbacks,
+// platforms without them are refused.
+type Options struct {
+	SlackSigningSecret string
+	// DiscordPublicKey is the application's public key, in hex.
+	DiscordPublicKey string
+	// TelegramSecretToken is the secret_token given to setWebhook.
+	TelegramSecretToken   string
+	LarkVerificationToken string
+	// DingTalkSecret is the CallbackSecret the buttons were signed with,
+	// their links are accepted for DingTalkMaxAge, see
+	// dingtalk.VerifyActionURL.
+	DingTalkSecret string
+	DingTalkMaxAge time.Duration
+
+	Logger logger.Logger
+}
+
+// Handler is an http.Handler for the callbacks of every platform.
+type Handler struct {
+	opt     Options
+	slack   *slack.Receiver
+	discord *discord.Receiver
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// New returns a Handler, an invalid DiscordPublicKey is ignored and logged.
+func New(opt Options) *Handler {
+	opt.Logger = logger.Default(opt.Logger)
+	h := &Handler{opt: opt, handlers: make(map[string]HandlerFunc)}
+	h.slack = h.slackReceiver()
+	if opt.DiscordPublicKey != "" {
+		rc, err := h.discordReceiver()
+		if err != nil {
+			opt.Logger.Error("invalid discord public key", logger.Platform(PlatformDiscord), logger.Err(err))
+		}
+		h.discord = rc
+	}
+	return h
+}
+
+// Handle registers fn for the buttons with actionID.
+func (h *Handler) Handle(actionID string, fn HandlerFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.handlers[actionID] = fn
+}
+
+// ServeHTTP dispatches on the last element of the path, see Slack, Discord,
+// Telegram, Lark and DingTalk.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler http.Handler
+	switch path.Base(r.URL.Path) {
+	case PlatformSlack:
+		handler = h.Slack()
+	case PlatformDiscord:
+		handler = h.Discord()
+	case PlatformTelegram:
+		handler = h.Telegram()
+	case PlatformLark:
+		handler = h.Lark()
+	case PlatformDingTalk:
+		handler = h.DingTalk()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	handler.ServeHTTP(w, r)
+}
+
+var (
+	errUnknownAction = errors.New("unknown action")
+	errInvalidToken  = errors.New("invalid verification token")
+	errNoPublicKey   = errors.New("no valid discord public key")
+)
+
+// handler returns the handler of actionID.
+func (h *Handler) handler(actionID string) (HandlerFunc, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	fn, ok := h.handlers[actionID]
+	return fn, ok
+}
+
+// dispatch runs the handler of the click's action.
+func (h *Handler) dispatch(ctx context.Context, click *Click) (string, error) {
+	fn, ok := h.handler(click.ActionID)
+	if !ok {
+		h.opt.Logger.Warn("callback for unknown action", logger.Platform(click.Platform), logger.F("action", click.ActionID))
+		return "", errUnknownAction
+	}
+	reply, err := fn(ctx, click)
+	if err != nil {
+		h.opt.Logger.Error("callback handler failed", logger.Platform(click.Platform), logger.F("action", click.ActionID), logger.Err(err))
+	}
+	return reply, err
+}
+
+// readBody reads the body of a POST request, writing the error response
+// when it fails.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// refuse answers a request that failed verification.
+func (h *Handler) refuse(w http.ResponseWriter, platform string, err error) {
+	h.opt.Logger.Warn("callback refused", logger.Platform(platform), logger.Err(err))
+	http.Error(w, "invalid signature", http.StatusUnauthorized)
+}
+
+// fail answers a click whose handler failed and the platform shows no reply.
+func fail(w http.ResponseWriter, err error) {
+	if err == errUnknownAction {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, "handler failed", http.StatusInternalServerError)
+}
@@ -0,0 +1,151 @@
+package callback
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/dingtalk"
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+func newTestHandler(opt Options) (*Handler, *[]*Click) {
+	opt.Logger = logger.Nop()
+	h := New(opt)
+	var clicks []*Click
+	h.Handle("silence", func(ctx context.Context, c *Click) (string, error) {
+		clicks = append(clicks, c)
+		return "Silenced for " + c.Value, nil
+	})
+	return h, &clicks
+}
+
+func serve(h http.Handler, r *http.Request) (int, string) {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	body, _ := ioutil.ReadAll(w.Result().Body)
+	return w.Code, string(body)
+}
+
+func TestSlack(t *testing.T) {
+	h, clicks := newTestHandler(Options{SlackSigningSecret: "8f742231b10e8888abcd99yyyzzz85a5"})
+	payload := `{"type":"block_actions","user":{"id":"U1"},"channel":{"id":"C1"},"actions":[{"action_id":"silence","value":"1h"}]}`
+	body := url.Values{"payload": {payload}}.Encode()
+
+	sign := func(secret string) *http.Request {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + ts + ":" + body))
+		r := httptest.NewRequest(http.MethodPost, "/callbacks/slack", strings.NewReader(body))
+		r.Header.Set("X-Slack-Request-Timestamp", ts)
+		r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return r
+	}
+
+	if code, _ := serve(h, sign("forged")); code != http.StatusUnauthorized {
+		t.Errorf("forged request: status %d", code)
+	}
+	if code, _ := serve(h, sign("8f742231b10e8888abcd99yyyzzz85a5")); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	if len(*clicks) != 1 || (*clicks)[0].Value != "1h" || (*clicks)[0].User != "U1" {
+		t.Errorf("clicks = %+v", *clicks)
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	h, clicks := newTestHandler(Options{DiscordPublicKey: hex.EncodeToString(pub)})
+
+	request := func(body string, key ed25519.PrivateKey) *http.Request {
+		ts := "1700000000"
+		r := httptest.NewRequest(http.MethodPost, "/callbacks/discord", strings.NewReader(body))
+		r.Header.Set("X-Signature-Timestamp", ts)
+		r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+		return r
+	}
+
+	if code, body := serve(h, request(`{"type":1}`, priv)); code != http.StatusOK || !strings.Contains(body, `"type":1`) {
+		t.Errorf("ping: %d %s", code, body)
+	}
+	_, other, _ := ed25519.GenerateKey(nil)
+	if code, _ := serve(h, request(`{"type":1}`, other)); code != http.StatusUnauthorized {
+		t.Errorf("forged ping: status %d", code)
+	}
+	if code, _ := serve(New(Options{Logger: logger.Nop()}), request(`{"type":1}`, priv)); code != http.StatusUnauthorized {
+		t.Errorf("ping without a public key: status %d", code)
+	}
+
+	data := action.Action{ID: "silence", Value: "30m"}.Data()
+	code, body := serve(h, request(`{"type":3,"data":{"custom_id":"`+data+`"},"member":{"user":{"id":"42"}}}`, priv))
+	if code != http.StatusOK || !strings.Contains(body, "Silenced for 30m") {
+		t.Errorf("click: %d %s", code, body)
+	}
+	if len(*clicks) != 1 || (*clicks)[0].User != "42" {
+		t.Errorf("clicks = %+v", *clicks)
+	}
+}
+
+func TestDingTalk(t *testing.T) {
+	h, clicks := newTestHandler(Options{DingTalkSecret: "callback-secret"})
+	link, err := dingtalk.ActionURL("https://example.com/callbacks/dingtalk?team=ops", "callback-secret", "msg-1",
+		action.Action{ID: "silence", Value: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if code, body := serve(h, httptest.NewRequest(http.MethodGet, link, nil)); code != http.StatusOK || !strings.Contains(body, "Silenced for 1h") {
+		t.Errorf("click: %d %s", code, body)
+	}
+	forged := strings.Replace(link, "value=1h", "value=1w", 1)
+	if code, _ := serve(h, httptest.NewRequest(http.MethodGet, forged, nil)); code != http.StatusUnauthorized {
+		t.Errorf("forged click: status %d", code)
+	}
+	if len(*clicks) != 1 || (*clicks)[0].MessageID != "msg-1" {
+		t.Errorf("clicks = %+v", *clicks)
+	}
+}
+
+func TestLark(t *testing.T) {
+	h, clicks := newTestHandler(Options{LarkVerificationToken: "lark-token"})
+	request := func(body string, ts time.Time) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/callbacks/lark", strings.NewReader(body))
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		sum := sha1.Sum([]byte(timestamp + "nonce" + "lark-token" + body))
+		r.Header.Set("X-Lark-Request-Timestamp", timestamp)
+		r.Header.Set("X-Lark-Request-Nonce", "nonce")
+		r.Header.Set("X-Lark-Signature", hex.EncodeToString(sum[:]))
+		return r
+	}
+
+	if code, body := serve(h, request(`{"type":"url_verification","challenge":"c1","token":"lark-token"}`, time.Now())); code != http.StatusOK || !strings.Contains(body, "c1") {
+		t.Errorf("url verification: %d %s", code, body)
+	}
+	if code, _ := serve(h, request(`{"type":"url_verification","challenge":"c1","token":"other"}`, time.Now())); code != http.StatusUnauthorized {
+		t.Errorf("url verification with another token: status %d", code)
+	}
+
+	click := `{"open_id":"ou_1","action":{"value":{"action_id":"silence","value":"1h"}}}`
+	if code, body := serve(h, request(click, time.Now())); code != http.StatusOK || !strings.Contains(body, "Silenced for 1h") {
+		t.Errorf("click: %d %s", code, body)
+	}
+	// a replayed callback is refused once it is too old
+	if code, _ := serve(h, request(click, time.Now().Add(-time.Hour))); code != http.StatusUnauthorized {
+		t.Errorf("stale click: status %d", code)
+	}
+	if len(*clicks) != 1 || (*clicks)[0].User != "ou_1" {
+		t.Errorf("clicks = %+v", *clicks)
+	}
+}
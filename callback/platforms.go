@@ -0,0 +1,258 @@
+package callback
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/dingtalk"
+	"github.com/ChainbotAI/go-notify/discord"
+	"github.com/ChainbotAI/go-notify/lark"
+	"github.com/ChainbotAI/go-notify/slack"
+	"github.com/ChainbotAI/go-notify/telegram"
+)
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Slack handles the interactivity requests of a Slack app with a
+// slack.Receiver, replies are sent to the user as ephemeral messages.
+func (h *Handler) Slack() http.Handler {
+	return h.slack
+}
+
+// slackReceiver returns the slack.Receiver behind Slack, which hands every
+// action to the handlers of h.
+func (h *Handler) slackReceiver() *slack.Receiver {
+	rc := slack.NewReceiver(slack.ReceiverOptions{
+		SigningSecret: h.opt.SlackSigningSecret,
+		Logger:        h.opt.Logger,
+	})
+	rc.HandleAction("", func(ctx context.Context, a *slack.BlockAction) (string, error) {
+		fn, ok := h.handler(a.ActionID)
+		if !ok {
+			return "", slack.ErrNoHandler
+		}
+		return fn(ctx, &Click{
+			Platform:  PlatformSlack,
+			ActionID:  a.ActionID,
+			Value:     a.Value,
+			User:      a.UserID,
+			Channel:   a.ChannelID,
+			MessageID: a.MessageTS,
+			Payload:   a.Payload,
+		})
+	})
+	return rc
+}
+
+// Discord handles the interactions endpoint of a Discord application with
+// a discord.Receiver, replies are sent to the user as ephemeral messages.
+// Without a valid DiscordPublicKey every request is refused.
+func (h *Handler) Discord() http.Handler {
+	if h.discord == nil {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.refuse(w, PlatformDiscord, errNoPublicKey)
+		})
+	}
+	return h.discord
+}
+
+// discordReceiver returns the discord.Receiver behind Discord, which hands
+// every button click to the handlers of h.
+func (h *Handler) discordReceiver() (*discord.Receiver, error) {
+	rc, err := discord.NewReceiver(discord.ReceiverOptions{
+		PublicKey: h.opt.DiscordPublicKey,
+		Logger:    h.opt.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rc.HandleComponent("", func(ctx context.Context, in *discord.ComponentInteraction) (*discord.Response, error) {
+		fn, ok := h.handler(in.ActionID)
+		if !ok {
+			return nil, discord.ErrNoHandler
+		}
+		reply, err := fn(ctx, &Click{
+			Platform:  PlatformDiscord,
+			ActionID:  in.ActionID,
+			Value:     in.Value,
+			User:      in.UserID,
+			Channel:   in.ChannelID,
+			MessageID: in.MessageID,
+			Payload:   in.Raw,
+		})
+		if err != nil || reply == "" {
+			return nil, err
+		}
+		return &discord.Response{Content: reply, Ephemeral: true}, nil
+	})
+	return rc, nil
+}
+
+type telegramUpdate struct {
+	CallbackQuery *struct {
+		ID   string `json:"id"`
+		From struct {
+			ID int64 `json:"id"`
+		} `json:"from"`
+		Message *struct {
+			MessageID int64 `json:"message_id"`
+			Chat      struct {
+				ID int64 `json:"id"`
+			} `json:"chat"`
+		} `json:"message"`
+		Data string `json:"data"`
+	} `json:"callback_query"`
+}
+
+// Telegram handles the webhook updates of a bot, replies answer the callback
+// query and show as a notification to the user.
+func (h *Handler) Telegram() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		if err := telegram.VerifyWebhook(h.opt.TelegramSecretToken, r.Header); err != nil {
+			h.refuse(w, PlatformTelegram, err)
+			return
+		}
+		u := &telegramUpdate{}
+		if err := json.Unmarshal(body, u); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		q := u.CallbackQuery
+		if q == nil {
+			// other updates are not ours, telegram retries unless they
+			// are acknowledged
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		id, value := action.ParseData(q.Data)
+		click := &Click{
+			Platform: PlatformTelegram,
+			ActionID: id,
+			Value:    value,
+			User:     strconv.FormatInt(q.From.ID, 10),
+			Payload:  body,
+		}
+		if q.Message != nil {
+			click.Channel = strconv.FormatInt(q.Message.Chat.ID, 10)
+			click.MessageID = strconv.FormatInt(q.Message.MessageID, 10)
+		}
+		reply, err := h.dispatch(r.Context(), click)
+		if err != nil {
+			reply = "Something went wrong"
+		}
+		// the answer is made as the response to the webhook request
+		writeJSON(w, map[string]interface{}{
+			"method":            "answerCallbackQuery",
+			"callback_query_id": q.ID,
+			"text":              reply,
+		})
+	})
+}
+
+type larkCallback struct {
+	Type          string `json:"type"`
+	Challenge     string `json:"challenge"`
+	Token         string `json:"token"`
+	OpenID        string `json:"open_id"`
+	OpenChatID    string `json:"open_chat_id"`
+	OpenMessageID string `json:"open_message_id"`
+	Action        struct {
+		Value map[string]string `json:"value"`
+	} `json:"action"`
+}
+
+// Lark handles the card callbacks of a Lark app, replies are shown as a
+// toast.
+func (h *Handler) Lark() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+		cb := &larkCallback{}
+		if err := json.Unmarshal(body, cb); err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+		if cb.Type == "url_verification" {
+			// the url check is not signed, it carries the token instead
+			if h.opt.LarkVerificationToken == "" ||
+				subtle.ConstantTimeCompare([]byte(cb.Token), []byte(h.opt.LarkVerificationToken)) != 1 {
+				h.refuse(w, PlatformLark, errInvalidToken)
+				return
+			}
+			writeJSON(w, map[string]string{"challenge": cb.Challenge})
+			return
+		}
+		if err := lark.VerifyCallback(h.opt.LarkVerificationToken, r.Header, body); err != nil {
+			h.refuse(w, PlatformLark, err)
+			return
+		}
+
+		click := &Click{
+			Platform:  PlatformLark,
+			ActionID:  cb.Action.Value["action_id"],
+			Value:     cb.Action.Value["value"],
+			User:      cb.OpenID,
+			Channel:   cb.OpenChatID,
+			MessageID: cb.OpenMessageID,
+			Payload:   body,
+		}
+		reply, err := h.dispatch(r.Context(), click)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		if reply == "" {
+			writeJSON(w, map[string]string{})
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"toast": map[string]string{"type": "info", "content": reply},
+		})
+	})
+}
+
+// DingTalk handles the links of DingTalk action buttons, made by
+// dingtalk.ActionURL. The reply is shown as the page the link opens.
+func (h *Handler) DingTalk() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		id, value, messageID, err := dingtalk.VerifyActionURL(h.opt.DingTalkSecret, query, h.opt.DingTalkMaxAge)
+		if err != nil {
+			h.refuse(w, PlatformDingTalk, err)
+			return
+		}
+
+		click := &Click{
+			Platform:  PlatformDingTalk,
+			ActionID:  id,
+			Value:     value,
+			MessageID: messageID,
+			Payload:   []byte(r.URL.RawQuery),
+		}
+		reply, err := h.dispatch(r.Context(), click)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+		if reply == "" {
+			reply = "Done"
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<!DOCTYPE html><html><body><p>" + html.EscapeString(reply) + "</p></body></html>"))
+	})
+}
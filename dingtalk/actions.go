@@ -0,0 +1,112 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+type actionButton struct {
+	Title     string `json:"title"`
+	ActionURL string `json:"actionURL"`
+}
+
+func (c *client) sendActionCard(ctx context.Context, title, text string) error {
+	btns := make([]actionButton, 0, len(c.opt.Actions))
+	for _, a := range c.opt.Actions {
+		link := a.URL
+		if !a.IsLink() {
+			var err error
+			link, err = ActionURL(c.opt.CallbackURL, c.opt.CallbackSecret, c.opt.MessageID, a)
+			if err != nil {
+				return err
+			}
+		}
+		btns = append(btns, actionButton{Title: a.Label, ActionURL: link})
+	}
+
+	return c.post(ctx, map[string]interface{}{
+		"msgtype": "actionCard",
+		"actionCard": map[string]interface{}{
+			"title":          title,
+			"text":           text,
+			"btnOrientation": "0",
+			"btns":           btns,
+		},
+	})
+}
+
+// DefaultActionMaxAge is how long the links made by ActionURL are accepted
+// unless VerifyActionURL is given another max age.
+const DefaultActionMaxAge = 24 * time.Hour
+
+// ActionURL returns the link that calls back with the action when its
+// button is clicked: callbackURL with the action id, value, the id of the
+// message the button is on, the time and a signature of them made with
+// secret in the query. The links are opened with GET, which link previews
+// do as well, so VerifyActionURL only accepts them for a while.
+func ActionURL(callbackURL, secret, messageID string, a action.Action) (string, error) {
+	if callbackURL == "" || secret == "" {
+		return "", fmt.Errorf("action %s: missing callback url or secret", a.ID)
+	}
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	ts := strconv.FormatInt(now().Unix(), 10)
+	q := u.Query()
+	q.Set("action", a.ID)
+	q.Set("value", a.Value)
+	q.Set("msg", messageID)
+	q.Set("ts", ts)
+	q.Set("sign", actionSign(secret, a.ID, a.Value, messageID, ts))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// VerifyActionURL checks the signature in the query of a link made by
+// ActionURL and returns the action id, value and message id. Links older
+// than maxAge, DefaultActionMaxAge when it is zero, are refused.
+func VerifyActionURL(secret string, query url.Values, maxAge time.Duration) (id, value, messageID string, err error) {
+	if secret == "" {
+		return "", "", "", errors.New("missing callback secret")
+	}
+	id, value, messageID = query.Get("action"), query.Get("value"), query.Get("msg")
+	if id == "" {
+		return "", "", "", errors.New("missing action")
+	}
+	ts := query.Get("ts")
+	if !hmac.Equal([]byte(actionSign(secret, id, value, messageID, ts)), []byte(query.Get("sign"))) {
+		return "", "", "", errors.New("invalid signature")
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", "", "", errors.New("invalid timestamp")
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultActionMaxAge
+	}
+	// a minute of clock skew between the sender and the receiver
+	if age := now().Sub(time.Unix(sec, 0)); age > maxAge || age < -time.Minute {
+		return "", "", "", errors.New("expired link")
+	}
+	return id, value, messageID, nil
+}
+
+// now is time.Now, replaced in tests.
+var now = time.Now
+
+func actionSign(secret string, fields ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(fields, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,48 @@
+package dingtalk
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+func TestVerifyActionURL(t *testing.T) {
+	defer func() { now = time.Now }()
+	sent := time.Unix(1700000000, 0)
+	now = func() time.Time { return sent }
+	link, err := ActionURL("https://example.com/callbacks/dingtalk", "secret", "msg-1", action.Action{ID: "ack", Value: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(link)
+
+	tests := []struct {
+		name    string
+		query   string
+		at      time.Time
+		maxAge  time.Duration
+		wantErr string
+	}{
+		{"fresh", u.RawQuery, sent.Add(time.Hour), 0, ""},
+		{"expired", u.RawQuery, sent.Add(DefaultActionMaxAge + time.Second), 0, "expired"},
+		{"short max age", u.RawQuery, sent.Add(time.Hour), time.Minute, "expired"},
+		{"from the future", u.RawQuery, sent.Add(-time.Hour), 0, "expired"},
+		{"other message", strings.Replace(u.RawQuery, "msg=msg-1", "msg=msg-2", 1), sent, 0, "signature"},
+		{"later timestamp", strings.Replace(u.RawQuery, "ts=1700000000", "ts=1800000000", 1), sent, 0, "signature"},
+	}
+	for _, tt := range tests {
+		now = func() time.Time { return tt.at }
+		query, _ := url.ParseQuery(tt.query)
+		id, value, messageID, err := VerifyActionURL("secret", query, tt.maxAge)
+		if tt.wantErr == "" {
+			if err != nil || id != "ack" || value != "1" || messageID != "msg-1" {
+				t.Errorf("%s: got %q %q %q %v", tt.name, id, value, messageID, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -1,27 +1,72 @@
 package dingtalk
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/imroc/req"
 	"net/url"
+	"strings"
 	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/redact"
+	"github.com/imroc/req"
 )
 
 type Options struct {
 	WebhookUrl string `json:"webhook_url"`
 	Secret     string `json:"secret"`
+	// Actions turn the message into an actionCard. DingTalk buttons only
+	// open links, the other actions link to CallbackURL signed with
+	// CallbackSecret, see ActionURL.
+	Actions        []action.Action `json:"-"`
+	CallbackURL    string          `json:"callback_url"`
+	CallbackSecret string          `json:"callback_secret"`
+	// MessageID identifies the message in the links of its actions.
+	MessageID string `json:"message_id,omitempty"`
+	// AtAll mentions everyone in the group, it has no effect on actionCards.
+	AtAll bool `json:"at_all"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the webhook url and signing secrets in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.WebhookUrl = redact.Secret(o.WebhookUrl)
+	o.Secret = redact.Secret(o.Secret)
+	o.CallbackSecret = redact.Secret(o.CallbackSecret)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.WebhookUrl, opt.Secret, opt.CallbackSecret)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 type Resp struct {
@@ -30,6 +75,70 @@ type Resp struct {
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.send(ctx, message))
+	logger.Delivery(ctx, c.opt.Logger, "dingtalk", c.opt.WebhookUrl, start, err)
+	return err
+}
+
+func (c *client) send(ctx context.Context, message string) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	if "" == message {
+		return errors.New("missing message")
+	}
+	if len(c.opt.Actions) > 0 {
+		title := strings.SplitN(message, "\n", 2)[0]
+		return c.sendActionCard(ctx, title, message)
+	}
+
+	return c.post(ctx, c.withAt(map[string]interface{}{
+		"msgtype": "text",
+		"text":    map[string]string{"content": message},
+	}))
+}
+
+// SendMarkdown sends text as a markdown message, title is what the
+// notification preview shows.
+func (c *client) SendMarkdown(ctx context.Context, title, text string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.sendMarkdown(ctx, title, text))
+	logger.Delivery(ctx, c.opt.Logger, "dingtalk", c.opt.WebhookUrl, start, err)
+	return err
+}
+
+func (c *client) sendMarkdown(ctx context.Context, title, text string) error {
+	if err := c.check(); err != nil {
+		return err
+	}
+
+	if "" == text {
+		return errors.New("missing message")
+	}
+	if len(c.opt.Actions) > 0 {
+		return c.sendActionCard(ctx, title, text)
+	}
+
+	return c.post(ctx, c.withAt(map[string]interface{}{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": title, "text": text},
+	}))
+}
+
+func (c *client) withAt(payload map[string]interface{}) map[string]interface{} {
+	if c.opt.AtAll {
+		payload["at"] = map[string]bool{"isAtAll": true}
+	}
+	return payload
+}
+
+func (c *client) check() error {
 	if "" == c.opt.WebhookUrl {
 		return errors.New("missing webhook url")
 	}
@@ -37,23 +146,28 @@ func (c *client) Send(message string) error {
 	if "" == c.opt.Secret {
 		return errors.New("missing secret")
 	}
+	return nil
+}
 
-	if "" == message {
-		return errors.New("missing message")
-	}
-
+func (c *client) post(ctx context.Context, payload interface{}) error {
 	sign, timestamp := c.getSign()
 
 	header := req.Header{
 		"Content-Type": "application/json",
 	}
 
-	dingUrl := fmt.Sprintf("%s&timestamp=%d&sign=%s", c.opt.WebhookUrl, timestamp, sign)
-	json := fmt.Sprintf("{\"msgtype\": \"text\",\"text\": {\"content\":\"%s\"}}", message)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
 
-	resp, _ := req.Post(dingUrl, json, header)
+	dingUrl := fmt.Sprintf("%s&timestamp=%d&sign=%s", c.opt.WebhookUrl, timestamp, sign)
+	resp, err := req.Post(dingUrl, string(body), header, ctx, tracing.HTTPClient)
+	if err != nil {
+		return err
+	}
 	r := &Resp{}
-	err := resp.ToJSON(&r)
+	err = resp.ToJSON(&r)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,108 @@
+package discord
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+// Component types and button styles of message components.
+// https://discord.com/developers/docs/interactions/message-components
+const (
+	componentActionRow = 1
+	componentButton    = 2
+
+	buttonPrimary   = 1
+	buttonSecondary = 2
+	buttonDanger    = 4
+	buttonLink      = 5
+)
+
+const (
+	maxRowButtons = 5
+	maxRows       = 5
+	maxCustomID   = 100
+)
+
+type component struct {
+	Type       int         `json:"type"`
+	Style      int         `json:"style,omitempty"`
+	Label      string      `json:"label,omitempty"`
+	CustomID   string      `json:"custom_id,omitempty"`
+	URL        string      `json:"url,omitempty"`
+	Components []component `json:"components,omitempty"`
+}
+
+// actionRows lays out actions as buttons, five per row.
+func actionRows(actions []action.Action) ([]component, error) {
+	if len(actions) > maxRows*maxRowButtons {
+		return nil, fmt.Errorf("discord accepts at most %d buttons, got %d", maxRows*maxRowButtons, len(actions))
+	}
+	var rows []component
+	for i, a := range actions {
+		button := component{Type: componentButton, Label: a.Label}
+		switch {
+		case a.IsLink():
+			button.Style, button.URL = buttonLink, a.URL
+		case len(a.Data()) > maxCustomID:
+			return nil, fmt.Errorf("action %s: id and value longer than %d bytes", a.ID, maxCustomID)
+		default:
+			button.CustomID = a.Data()
+			button.Style = buttonSecondary
+			if a.Style == action.StylePrimary {
+				button.Style = buttonPrimary
+			} else if a.Style == action.StyleDanger {
+				button.Style = buttonDanger
+			}
+		}
+		if i%maxRowButtons == 0 {
+			rows = append(rows, component{Type: componentActionRow})
+		}
+		row := &rows[len(rows)-1]
+		row.Components = append(row.Components, button)
+	}
+	return rows, nil
+}
+
+func checkResp(resp *http.Response) error {
+	if resp == nil || resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("discord error: %s %s", resp.Status, string(body))
+}
+
+// ParsePublicKey decodes the hex public key of a Discord application.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := hex.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifyInteraction checks the Ed25519 signature Discord puts on
+// interaction requests.
+// https://discord.com/developers/docs/interactions/overview#setting-up-an-endpoint
+func VerifyInteraction(publicKey ed25519.PublicKey, header http.Header, body []byte) error {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("missing public key")
+	}
+	sig, err := hex.DecodeString(header.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return errors.New("missing signature")
+	}
+	ts := header.Get("X-Signature-Timestamp")
+	if ts == "" {
+		return errors.New("missing signature timestamp")
+	}
+	if !ed25519.Verify(publicKey, append([]byte(ts), body...), sig) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
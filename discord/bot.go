@@ -0,0 +1,206 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// BotApiURL is the REST API the bot mode posts to, see Options.Bot.
+var BotApiURL = "https://discord.com/api/v10/"
+
+// botMessage is a message posted by a bot.
+type botMessage struct {
+	Content         string           `json:"content,omitempty"`
+	TTS             bool             `json:"tts,omitempty"`
+	Flags           int              `json:"flags,omitempty"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+	Embeds          []*Embed         `json:"embeds,omitempty"`
+	Components      []component      `json:"components,omitempty"`
+}
+
+// botThread starts a forum post.
+type botThread struct {
+	Name    string      `json:"name"`
+	Message *botMessage `json:"message"`
+}
+
+// botMessage returns the message of the options posting content.
+func (c *client) botMessage(content string) (*botMessage, error) {
+	wh, err := c.webhook(content)
+	if err != nil {
+		return nil, err
+	}
+	return &botMessage{
+		Content:         wh.Content,
+		TTS:             wh.TTS,
+		Flags:           wh.Flags,
+		AllowedMentions: wh.AllowedMentions,
+	}, nil
+}
+
+// maxDMs is how many direct message channels are cached, the cache starts
+// over beyond.
+const maxDMs = 1024
+
+var (
+	dmsMu sync.Mutex
+	// dms are the direct message channels by token hash and user id
+	dms = map[string]string{}
+)
+
+// botChannel returns the id of the channel the bot posts to: the thread,
+// the direct messages of the user or the channel of the options.
+func (c *client) botChannel(ctx context.Context) (string, error) {
+	if c.opt.ThreadID != "" {
+		// threads are channels of their own
+		return c.opt.ThreadID, nil
+	}
+	if c.opt.User == "" {
+		if c.opt.Channel == "" {
+			return "", errors.New("missing channel")
+		}
+		return c.opt.Channel, nil
+	}
+
+	sum := sha256.Sum256([]byte(c.opt.Token))
+	key := hex.EncodeToString(sum[:8]) + ":" + c.opt.User
+	dmsMu.Lock()
+	id, ok := dms[key]
+	dmsMu.Unlock()
+	if ok {
+		return id, nil
+	}
+	body, _ := json.Marshal(map[string]string{"recipient_id": c.opt.User})
+	channel := &struct {
+		ID string `json:"id"`
+	}{}
+	if err := c.do(ctx, http.MethodPost, "users/@me/channels", "application/json", body, channel); err != nil {
+		return "", fmt.Errorf("open direct messages: %w", err)
+	}
+	dmsMu.Lock()
+	if len(dms) >= maxDMs {
+		dms = map[string]string{}
+	}
+	dms[key] = channel.ID
+	dmsMu.Unlock()
+	return channel.ID, nil
+}
+
+// sendBot posts text to the channel of the options as a bot. With a
+// ThreadName it starts a post in the forum channel.
+func (c *client) sendBot(ctx context.Context, text string) (*receipt.Receipt, error) {
+	channel, err := c.botChannel(ctx)
+	if err != nil {
+		return nil, err
+	}
+	content, embeds, err := c.content(text)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := c.botMessage(content)
+	if err != nil {
+		return nil, err
+	}
+	msg.Embeds = embeds
+	if len(c.opt.Actions) > 0 {
+		if msg.Components, err = actionRows(c.opt.Actions); err != nil {
+			return nil, err
+		}
+	}
+
+	path, v := "channels/"+channel+"/messages", interface{}(msg)
+	if c.opt.ThreadName != "" && c.opt.ThreadID == "" {
+		path, v = "channels/"+channel+"/threads", &botThread{Name: c.opt.ThreadName, Message: msg}
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	if err := c.do(ctx, http.MethodPost, path, "application/json", body, &raw); err != nil {
+		return nil, err
+	}
+
+	r := &receipt.Receipt{
+		Platform: "discord",
+		Channel:  channel,
+		ThreadID: c.opt.ThreadID,
+		Raw:      raw,
+	}
+	m := &Message{}
+	if c.opt.ThreadName != "" && c.opt.ThreadID == "" {
+		// a forum post answers with the thread, the message in it
+		thread := &struct {
+			ID      string   `json:"id"`
+			Message *Message `json:"message"`
+		}{Message: m}
+		if err := json.Unmarshal(raw, thread); err != nil {
+			return nil, err
+		}
+		r.Channel, r.ThreadID = thread.ID, thread.ID
+	} else if err := json.Unmarshal(raw, m); err != nil {
+		return nil, err
+	}
+	r.MessageIDs = []string{m.ID}
+	r.SentAt = m.Timestamp
+	if r.SentAt.IsZero() {
+		r.SentAt = time.Now()
+	}
+	return r, nil
+}
+
+// do sends a request to path below BotApiURL as the bot, within the rate
+// limits, and decodes the answer into v unless it is nil.
+func (c *client) do(ctx context.Context, method, path, contentType string, body []byte, v interface{}) error {
+	limiter := limiterFor(c.opt.Token)
+	rt, major := route(method, path)
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx, rt, major); err != nil {
+			return err
+		}
+		var rb io.Reader
+		if body != nil {
+			rb = bytes.NewReader(body)
+		}
+		request, err := http.NewRequestWithContext(ctx, method, BotApiURL+path, rb)
+		if err != nil {
+			return err
+		}
+		request.Header.Set("Authorization", "Bot "+c.opt.Token)
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+		resp, err := tracing.HTTPClient.Do(request)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if limiter.update(rt, major, resp, data) && attempt < maxRateLimitRetries {
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("discord error: %s %s", resp.Status, string(data))
+		}
+		if v == nil || len(data) == 0 {
+			return nil
+		}
+		return json.Unmarshal(data, v)
+	}
+}
@@ -0,0 +1,103 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBotDirectMessage(t *testing.T) {
+	var calls []string
+	limited := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bot bot-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch r.URL.Path {
+		case "/users/@me/channels":
+			w.Write([]byte(`{"id":"555"}`))
+		case "/channels/555/messages":
+			w.Header().Set("X-RateLimit-Bucket", "abc")
+			if !limited {
+				limited = true
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"message":"You are being rate limited.","retry_after":0.05,"global":false}`))
+				return
+			}
+			// the next request must wait for the reset
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset-After", "0.05")
+			w.Write([]byte(`{"id":"42","channel_id":"555","timestamp":"2024-05-01T10:00:00+00:00"}`))
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { BotApiURL = url }(BotApiURL)
+	BotApiURL = srv.URL + "/"
+
+	ctx := context.Background()
+	c := New(Options{Token: "bot-token", Bot: true, User: "8001"})
+	start := time.Now()
+	r, err := c.SendWithReceipt(ctx, "your deploy finished")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Channel != "555" || r.MessageID() != "42" {
+		t.Errorf("receipt %+v", r)
+	}
+	if _, err := c.SendWithReceipt(ctx, "and again"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("rate limits not waited for, took %s", elapsed)
+	}
+	// the direct message channel is opened once
+	want := []string{"POST /users/@me/channels", "POST /channels/555/messages", "POST /channels/555/messages", "POST /channels/555/messages"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls %q, want %q", calls, want)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d: %q, want %q", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	tests := []struct{ method, path, route, major string }{
+		{"POST", "channels/1/messages", "POST channels/1/messages", "1"},
+		{"PATCH", "channels/1/messages/99", "PATCH channels/1/messages/{id}", "1"},
+		{"POST", "users/@me/channels", "POST users/@me/channels", ""},
+	}
+	for _, tt := range tests {
+		route, major := route(tt.method, tt.path)
+		if route != tt.route || major != tt.major {
+			t.Errorf("%s %s: got %q %q", tt.method, tt.path, route, major)
+		}
+	}
+}
+
+func TestRateLimiterCountsAdmitted(t *testing.T) {
+	l := &rateLimiter{routes: make(map[string]string), buckets: make(map[string]*bucket)}
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Limit", "2")
+	resp.Header.Set("X-RateLimit-Remaining", "2")
+	resp.Header.Set("X-RateLimit-Reset-After", "0.05")
+	l.update("POST channels/1/messages", "1", resp, nil)
+
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx, "POST channels/1/messages", "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the third request is in a new window
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("third request not held back, took %s", elapsed)
+	}
+}
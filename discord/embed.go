@@ -0,0 +1,187 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Embed limits, messages breaking them are refused by Discord.
+// https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	MaxEmbeds           = 10
+	MaxEmbedTotal       = 6000
+	MaxEmbedTitle       = 256
+	MaxEmbedDescription = 4096
+	MaxEmbedFields      = 25
+	MaxEmbedFieldName   = 256
+	MaxEmbedFieldValue  = 1024
+	MaxEmbedFooter      = 2048
+	MaxEmbedAuthor      = 256
+)
+
+// Embed is a rich content block of a message, with a color bar beside it.
+type Embed struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	// Color is the bar beside the embed, as 0xrrggbb. Options.Color
+	// applies when it is 0.
+	Color     int           `json:"color,omitempty"`
+	Fields    []*EmbedField `json:"fields,omitempty"`
+	Author    *EmbedAuthor  `json:"author,omitempty"`
+	Footer    *EmbedFooter  `json:"footer,omitempty"`
+	Thumbnail *EmbedMedia   `json:"thumbnail,omitempty"`
+	Image     *EmbedMedia   `json:"image,omitempty"`
+	Timestamp *time.Time    `json:"timestamp,omitempty"`
+}
+
+// EmbedField is a name and value, inline fields are shown side by side.
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type EmbedAuthor struct {
+	Name    string `json:"name"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+type EmbedFooter struct {
+	Text    string `json:"text"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedMedia is the image or thumbnail at URL.
+type EmbedMedia struct {
+	URL string `json:"url"`
+}
+
+// AddField appends a field and returns e.
+func (e *Embed) AddField(name, value string, inline bool) *Embed {
+	e.Fields = append(e.Fields, &EmbedField{Name: name, Value: value, Inline: inline})
+	return e
+}
+
+func checkLength(what, s string, max int) (int, error) {
+	n := utf8.RuneCountInString(s)
+	if n > max {
+		return n, fmt.Errorf("%s of %d characters, the limit is %d", what, n, max)
+	}
+	return n, nil
+}
+
+// length checks e against the limits of one embed and returns the number
+// of characters counting towards MaxEmbedTotal.
+func (e *Embed) length() (int, error) {
+	total := 0
+	add := func(what, s string, max int) error {
+		n, err := checkLength(what, s, max)
+		total += n
+		return err
+	}
+	if err := add("title", e.Title, MaxEmbedTitle); err != nil {
+		return 0, err
+	}
+	if err := add("description", e.Description, MaxEmbedDescription); err != nil {
+		return 0, err
+	}
+	if len(e.Fields) > MaxEmbedFields {
+		return 0, fmt.Errorf("%d fields, the limit is %d", len(e.Fields), MaxEmbedFields)
+	}
+	for i, f := range e.Fields {
+		if f == nil || f.Name == "" || f.Value == "" {
+			return 0, fmt.Errorf("field %d: missing name or value", i)
+		}
+		if err := add("field name", f.Name, MaxEmbedFieldName); err != nil {
+			return 0, err
+		}
+		if err := add("field value", f.Value, MaxEmbedFieldValue); err != nil {
+			return 0, err
+		}
+	}
+	if e.Footer != nil {
+		if err := add("footer", e.Footer.Text, MaxEmbedFooter); err != nil {
+			return 0, err
+		}
+	}
+	if e.Author != nil {
+		if err := add("author", e.Author.Name, MaxEmbedAuthor); err != nil {
+			return 0, err
+		}
+	}
+	if total == 0 && e.Image == nil && e.Thumbnail == nil {
+		return 0, errors.New("empty embed")
+	}
+	return total, nil
+}
+
+// ValidateEmbeds checks the embeds of a message against the Discord limits.
+func ValidateEmbeds(embeds []*Embed) error {
+	if len(embeds) > MaxEmbeds {
+		return fmt.Errorf("%d embeds, the limit is %d", len(embeds), MaxEmbeds)
+	}
+	total := 0
+	for i, e := range embeds {
+		if e == nil {
+			return fmt.Errorf("embed %d is nil", i)
+		}
+		n, err := e.length()
+		if err != nil {
+			return fmt.Errorf("embed %d: %w", i, err)
+		}
+		total += n
+	}
+	if total > MaxEmbedTotal {
+		return fmt.Errorf("embeds of %d characters, the limit is %d", total, MaxEmbedTotal)
+	}
+	return nil
+}
+
+// parseColor converts "#rrggbb" to the integer Discord expects.
+func parseColor(s string) (int, error) {
+	c, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || c > 0xffffff {
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+	return int(c), nil
+}
+
+// content returns the content and embeds showing text with the color and
+// embeds of the options. Without embeds, a color moves the text into one.
+func (c *client) content(text string) (string, []*Embed, error) {
+	color := 0
+	if c.opt.Color != "" {
+		var err error
+		if color, err = parseColor(c.opt.Color); err != nil {
+			return "", nil, err
+		}
+	}
+	if len(c.opt.Embeds) == 0 {
+		if c.opt.Color == "" {
+			return text, nil, nil
+		}
+		return "", []*Embed{{Description: text, Color: color}}, nil
+	}
+
+	embeds := make([]*Embed, len(c.opt.Embeds))
+	for i, e := range c.opt.Embeds {
+		if e == nil {
+			return "", nil, fmt.Errorf("embed %d is nil", i)
+		}
+		embed := *e
+		if embed.Color == 0 {
+			embed.Color = color
+		}
+		embeds[i] = &embed
+	}
+	if err := ValidateEmbeds(embeds); err != nil {
+		return "", nil, err
+	}
+	return text, embeds, nil
+}
@@ -0,0 +1,36 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateEmbeds(t *testing.T) {
+	full := func() *Embed {
+		return &Embed{Title: "t", Description: strings.Repeat("a", MaxEmbedDescription)}
+	}
+	tooMany := make([]*Embed, MaxEmbeds+1)
+	for i := range tooMany {
+		tooMany[i] = &Embed{Title: "t"}
+	}
+
+	tests := map[string]struct {
+		embeds  []*Embed
+		wantErr bool
+	}{
+		"ok":          {[]*Embed{(&Embed{Title: "Disk full", Color: 0xf44336}).AddField("host", "db-1", true)}, false},
+		"image only":  {[]*Embed{{Image: &EmbedMedia{URL: "https://example.com/a.png"}}}, false},
+		"empty":       {[]*Embed{{}}, true},
+		"title":       {[]*Embed{{Title: strings.Repeat("a", MaxEmbedTitle+1)}}, true},
+		"field value": {[]*Embed{(&Embed{}).AddField("host", strings.Repeat("a", MaxEmbedFieldValue+1), false)}, true},
+		"field name":  {[]*Embed{(&Embed{Title: "t"}).AddField("", "v", false)}, true},
+		"embeds":      {tooMany, true},
+		// each fits, together they pass the 6000 characters
+		"total": {[]*Embed{full(), full()}, true},
+	}
+	for name, tt := range tests {
+		if err := ValidateEmbeds(tt.embeds); (err != nil) != tt.wantErr {
+			t.Errorf("%s: error %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// MaxAttachments is the number of files Discord accepts in one message.
+const MaxAttachments = 10
+
+type webhookAttachment struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+type webhookFiles struct {
+	*Webhook
+	Attachments []webhookAttachment `json:"attachments"`
+}
+
+type botFiles struct {
+	*botMessage
+	Attachments []webhookAttachment `json:"attachments"`
+}
+
+// SendAttachments posts message with files to the webhook, or as the bot,
+// as a multipart request.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the id of the
+// created message like SendWithReceipt.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.sendAttachments(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "discord", c.opt.Channel, start, err)
+	return r, err
+}
+
+func (c *client) sendAttachments(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	if "" == c.opt.Token {
+		return nil, errors.New("missing token")
+	}
+
+	if "" == c.opt.Channel && !c.opt.Bot {
+		return nil, errors.New("missing channel")
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("missing attachment")
+	}
+	if len(files) > MaxAttachments {
+		return nil, fmt.Errorf("discord accepts at most %d attachments, got %d", MaxAttachments, len(files))
+	}
+
+	var attachments []webhookAttachment
+	for i, f := range files {
+		attachments = append(attachments, webhookAttachment{ID: i, Filename: f.FileName()})
+	}
+	var payload interface{}
+	if c.opt.Bot {
+		msg, err := c.botMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		payload = &botFiles{botMessage: msg, Attachments: attachments}
+	} else {
+		wh, err := c.webhook(message)
+		if err != nil {
+			return nil, err
+		}
+		payload = &webhookFiles{Webhook: wh, Attachments: attachments}
+	}
+	body, contentType, err := multipartBody(payload, files)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw json.RawMessage
+	if c.opt.Bot {
+		channel, err := c.botChannel(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.do(ctx, http.MethodPost, "channels/"+channel+"/messages", contentType, body, &raw); err != nil {
+			return nil, err
+		}
+	} else {
+		// wait=true makes Discord return the message rather than no content
+		request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.webhookURL(url.Values{"wait": {"true"}}), bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Content-Type", contentType)
+		resp, err := tracing.HTTPClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if err := checkResp(resp); err != nil {
+			return nil, err
+		}
+		if raw, err = ioutil.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Message{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, m); err != nil {
+			return nil, err
+		}
+	}
+	r := &receipt.Receipt{
+		Platform:   "discord",
+		Channel:    m.ChannelID,
+		MessageIDs: []string{m.ID},
+		ThreadID:   c.opt.ThreadID,
+		SentAt:     m.Timestamp,
+		Raw:        receipt.JSON(raw),
+	}
+	if r.SentAt.IsZero() {
+		r.SentAt = time.Now()
+	}
+	return r, nil
+}
+
+// multipartBody returns the body and content type of a message with files,
+// payload being its json.
+func multipartBody(payload interface{}, files []*attachment.Attachment) ([]byte, string, error) {
+	pj, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="payload_json"`},
+		"Content-Type":        {"application/json"},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	part.Write(pj)
+	for i, f := range files {
+		data, err := f.Bytes()
+		if err != nil {
+			return nil, "", err
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {fmt.Sprintf(`form-data; name="files[%d]"; filename=%s`, i, strconv.Quote(f.FileName()))},
+			"Content-Type":        {f.Type()},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		part.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return body.Bytes(), w.FormDataContentType(), nil
+}
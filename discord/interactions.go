@@ -0,0 +1,335 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// Interaction and interaction response types.
+// https://discord.com/developers/docs/interactions/receiving-and-responding
+const (
+	interactionPing      = 1
+	interactionCommand   = 2
+	interactionComponent = 3
+
+	responsePong            = 1
+	responseMessage         = 4
+	responseDeferredMessage = 5
+	responseDeferredUpdate  = 6
+	responseUpdate          = 7
+
+	messageFlagEphemeral = 1 << 6
+)
+
+// Interaction is what component and command interactions have in common.
+// ApplicationID and Token make a webhook to follow up with for 15 minutes:
+//
+//	discord.New(discord.Options{Channel: in.ApplicationID, Token: in.Token}).
+//		Edit(ctx, "@original", "Done")
+type Interaction struct {
+	ID            string
+	ApplicationID string
+	Token         string
+	GuildID       string
+	ChannelID     string
+	UserID        string
+	UserName      string
+
+	// Raw is the interaction as Discord sent it.
+	Raw json.RawMessage
+}
+
+// ComponentInteraction is a click on a button of a message.
+type ComponentInteraction struct {
+	Interaction
+	CustomID string
+	// ActionID and Value are the CustomID of a button made from an
+	// action.Action.
+	ActionID  string
+	Value     string
+	MessageID string
+}
+
+// CommandInteraction is a slash command.
+type CommandInteraction struct {
+	Interaction
+	Name string
+	// Options are the options given by name, those of a subcommand
+	// included; strings, float64 numbers and bools.
+	Options map[string]interface{}
+}
+
+// Response is the answer to an interaction.
+type Response struct {
+	Content         string
+	Embeds          []*Embed
+	Actions         []action.Action
+	AllowedMentions *AllowedMentions
+	// Ephemeral shows a new message only to the user.
+	Ephemeral bool
+	// Update edits the message of the clicked button rather than sending a
+	// new one: its content and buttons are replaced, its embeds only when
+	// Embeds is set.
+	Update bool
+}
+
+// ComponentHandler handles a button click. A nil response acknowledges it
+// without changing anything.
+type ComponentHandler func(ctx context.Context, in *ComponentInteraction) (*Response, error)
+
+// CommandHandler handles a slash command. A nil response shows the bot
+// thinking, to follow up on within 15 minutes, see Interaction.
+type CommandHandler func(ctx context.Context, in *CommandInteraction) (*Response, error)
+
+// ReceiverOptions configures a Receiver.
+type ReceiverOptions struct {
+	// PublicKey is the application's public key, in hex.
+	PublicKey string
+
+	Logger logger.Logger
+}
+
+// Receiver is an http.Handler for the interactions endpoint of a Discord
+// application. Requests are verified with the public key, pings are
+// answered and button clicks and slash commands are routed to the handlers
+// registered for their action id or command name. Discord wants an answer
+// within three seconds.
+//
+//	r, err := discord.NewReceiver(discord.ReceiverOptions{PublicKey: key})
+//	r.HandleComponent("ack", func(ctx context.Context, in *discord.ComponentInteraction) (*discord.Response, error) {
+//		return &discord.Response{Update: true, Content: "Acknowledged by <@" + in.UserID + ">"}, ack(in.Value)
+//	})
+//	http.Handle("/discord", r)
+type Receiver struct {
+	opt ReceiverOptions
+	key ed25519.PublicKey
+
+	mu         sync.RWMutex
+	components map[string]ComponentHandler
+	commands   map[string]CommandHandler
+}
+
+// NewReceiver returns a Receiver without handlers, it fails on an invalid
+// public key.
+func NewReceiver(opt ReceiverOptions) (*Receiver, error) {
+	key, err := ParsePublicKey(opt.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	opt.Logger = logger.Default(opt.Logger)
+	return &Receiver{
+		opt:        opt,
+		key:        key,
+		components: make(map[string]ComponentHandler),
+		commands:   make(map[string]CommandHandler),
+	}, nil
+}
+
+// HandleComponent registers fn for the buttons with actionID, see
+// action.Action, or for the buttons without a handler of their own when
+// actionID is empty. Such a handler returns ErrNoHandler for the actions it
+// does not know either.
+func (rc *Receiver) HandleComponent(actionID string, fn ComponentHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.components[actionID] = fn
+}
+
+// HandleCommand registers fn for the slash command name, without its slash.
+func (rc *Receiver) HandleCommand(name string, fn CommandHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.commands[name] = fn
+}
+
+// ErrNoHandler answers the interactions no handler is registered for.
+var ErrNoHandler = errors.New("no handler")
+
+// maxInteractionBody is the largest request read.
+const maxInteractionBody = 1 << 20
+
+type interactionOption struct {
+	Name    string              `json:"name"`
+	Value   interface{}         `json:"value"`
+	Options []interactionOption `json:"options"`
+}
+
+type interactionUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+type interaction struct {
+	ID            string `json:"id"`
+	ApplicationID string `json:"application_id"`
+	Type          int    `json:"type"`
+	Token         string `json:"token"`
+	GuildID       string `json:"guild_id"`
+	ChannelID     string `json:"channel_id"`
+	Member        *struct {
+		User interactionUser `json:"user"`
+	} `json:"member"`
+	User    *interactionUser `json:"user"`
+	Message *struct {
+		ID string `json:"id"`
+	} `json:"message"`
+	Data struct {
+		CustomID string              `json:"custom_id"`
+		Name     string              `json:"name"`
+		Options  []interactionOption `json:"options"`
+	} `json:"data"`
+}
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxInteractionBody))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	// Discord checks that forged requests are refused before it accepts
+	// the endpoint
+	if err := VerifyInteraction(rc.key, r.Header, body); err != nil {
+		rc.opt.Logger.Warn("discord interaction refused", logger.Platform("discord"), logger.Err(err))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	in := &interaction{}
+	if err := json.Unmarshal(body, in); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	base := Interaction{
+		ID:            in.ID,
+		ApplicationID: in.ApplicationID,
+		Token:         in.Token,
+		GuildID:       in.GuildID,
+		ChannelID:     in.ChannelID,
+		Raw:           body,
+	}
+	if in.Member != nil {
+		base.UserID, base.UserName = in.Member.User.ID, in.Member.User.Username
+	} else if in.User != nil {
+		base.UserID, base.UserName = in.User.ID, in.User.Username
+	}
+
+	var (
+		resp    *Response
+		deferTo = responseDeferredUpdate
+		found   bool
+		name    string
+	)
+	switch in.Type {
+	case interactionPing:
+		writeJSON(w, map[string]int{"type": responsePong})
+		return
+	case interactionComponent:
+		c := &ComponentInteraction{Interaction: base, CustomID: in.Data.CustomID}
+		c.ActionID, c.Value = action.ParseData(c.CustomID)
+		if in.Message != nil {
+			c.MessageID = in.Message.ID
+		}
+		name = c.ActionID
+		rc.mu.RLock()
+		fn, ok := rc.components[name]
+		if !ok {
+			fn, ok = rc.components[""]
+		}
+		rc.mu.RUnlock()
+		if found = ok; ok {
+			resp, err = fn(r.Context(), c)
+		}
+	case interactionCommand:
+		c := &CommandInteraction{Interaction: base, Name: in.Data.Name, Options: map[string]interface{}{}}
+		addOptions(c.Options, in.Data.Options)
+		deferTo, name = responseDeferredMessage, c.Name
+		rc.mu.RLock()
+		fn, ok := rc.commands[name]
+		rc.mu.RUnlock()
+		if found = ok; ok {
+			resp, err = fn(r.Context(), c)
+		}
+	default:
+		http.Error(w, "unsupported interaction", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case !found || errors.Is(err, ErrNoHandler):
+		rc.opt.Logger.Warn("discord interaction without handler", logger.Platform("discord"), logger.F("name", name))
+		resp = &Response{Content: "This is no longer handled", Ephemeral: true}
+	case err != nil:
+		rc.opt.Logger.Error("discord interaction handler failed", logger.Platform("discord"), logger.F("name", name), logger.Err(err))
+		resp = &Response{Content: "Something went wrong", Ephemeral: true}
+	case resp == nil:
+		writeJSON(w, map[string]int{"type": deferTo})
+		return
+	}
+	answer, err := resp.answer()
+	if err != nil {
+		rc.opt.Logger.Error("invalid discord interaction response", logger.Platform("discord"), logger.F("name", name), logger.Err(err))
+		answer = map[string]interface{}{
+			"type": responseMessage,
+			"data": map[string]interface{}{"content": "Something went wrong", "flags": messageFlagEphemeral},
+		}
+	}
+	writeJSON(w, answer)
+}
+
+// addOptions flattens the options of a command and its subcommands.
+func addOptions(to map[string]interface{}, options []interactionOption) {
+	for _, o := range options {
+		if o.Value != nil {
+			to[o.Name] = o.Value
+		}
+		addOptions(to, o.Options)
+	}
+}
+
+// answer returns the interaction response of r.
+func (r *Response) answer() (map[string]interface{}, error) {
+	data := map[string]interface{}{"content": r.Content}
+	if r.Embeds != nil {
+		if err := ValidateEmbeds(r.Embeds); err != nil {
+			return nil, err
+		}
+		data["embeds"] = r.Embeds
+	}
+	components, err := actionRows(r.Actions)
+	if err != nil {
+		return nil, err
+	}
+	if components != nil {
+		data["components"] = components
+	} else if r.Update {
+		// removes the buttons that were clicked
+		data["components"] = []component{}
+	}
+	if r.AllowedMentions != nil {
+		data["allowed_mentions"] = r.AllowedMentions
+	}
+	typ := responseMessage
+	if r.Update {
+		typ = responseUpdate
+	} else if r.Ephemeral {
+		data["flags"] = messageFlagEphemeral
+	}
+	return map[string]interface{}{"type": typ, "data": data}, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
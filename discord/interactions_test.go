@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+func signedInteraction(key ed25519.PrivateKey, body string) *http.Request {
+	ts := "1700000000"
+	r := httptest.NewRequest(http.MethodPost, "/discord", strings.NewReader(body))
+	r.Header.Set("X-Signature-Timestamp", ts)
+	r.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+	return r
+}
+
+func TestReceiver(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReceiver(ReceiverOptions{PublicKey: "nope"}); err == nil {
+		t.Error("invalid public key accepted")
+	}
+	rc, err := NewReceiver(ReceiverOptions{PublicKey: hex.EncodeToString(pub), Logger: logger.Nop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.HandleComponent("ack", func(ctx context.Context, in *ComponentInteraction) (*Response, error) {
+		return &Response{Update: true, Content: "ack " + in.Value + " by " + in.UserID}, nil
+	})
+	rc.HandleComponent("", func(ctx context.Context, in *ComponentInteraction) (*Response, error) {
+		if in.ActionID != "snooze" {
+			return nil, ErrNoHandler
+		}
+		return &Response{Content: "snoozed", Ephemeral: true}, nil
+	})
+	rc.HandleCommand("status", func(ctx context.Context, in *CommandInteraction) (*Response, error) {
+		return &Response{Content: "status of " + in.Options["service"].(string), Ephemeral: true}, nil
+	})
+
+	tests := []struct {
+		name, body string
+		status     int
+		answer     string
+	}{
+		{"ping", `{"type":1}`, 200, `{"type":1}`},
+		{"component", `{"type":3,"member":{"user":{"id":"7"}},"message":{"id":"9"},"data":{"custom_id":"ack:incident-1"}}`,
+			200, `{"data":{"components":[],"content":"ack incident-1 by 7"},"type":7}`},
+		{"command", `{"type":2,"user":{"id":"7"},"data":{"name":"status","options":[{"name":"service","value":"api"}]}}`,
+			200, `{"data":{"content":"status of api","flags":64},"type":4}`},
+		{"other component", `{"type":3,"data":{"custom_id":"snooze:1h"}}`,
+			200, `{"data":{"content":"snoozed","flags":64},"type":4}`},
+		{"unknown", `{"type":3,"data":{"custom_id":"other"}}`,
+			200, `{"data":{"content":"This is no longer handled","flags":64},"type":4}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rc.ServeHTTP(w, signedInteraction(priv, tt.body))
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d", tt.name, w.Code)
+			continue
+		}
+		var got interface{}
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if g, _ := json.Marshal(got); string(g) != tt.answer {
+			t.Errorf("%s: answer %s, want %s", tt.name, g, tt.answer)
+		}
+	}
+
+	// forged requests must be refused
+	r := signedInteraction(priv, `{"type":1}`)
+	r.Header.Set("X-Signature-Timestamp", "1700000001")
+	w := httptest.NewRecorder()
+	rc.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("forged request: status %d", w.Code)
+	}
+}
@@ -1,8 +1,17 @@
 package discord
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/imroc/req"
 )
 
@@ -11,63 +20,245 @@ var (
 )
 
 type Options struct {
+	// Token and Channel are the token and id of the webhook, or the bot
+	// token and channel id with Bot.
 	Token   string `json:"token"`
 	Channel string `json:"channel"`
 	Text    string `json:"text"`
+	// Bot posts with the REST API as a bot, which can post to any channel it
+	// sees and to the direct messages of User rather than to Channel.
+	Bot  bool   `json:"bot,omitempty"`
+	User string `json:"user,omitempty"`
+	// Actions are shown as buttons below the text, buttons other than links
+	// need a webhook owned by an application.
+	Actions []action.Action `json:"-"`
+	// Color is the bar beside the message, as "#rrggbb", the text is sent
+	// as an embed to show it unless there are Embeds.
+	Color string `json:"-"`
+	// Embeds are shown below the text, which may then be empty.
+	Embeds []*Embed `json:"-"`
+	// ThreadID posts the message in a thread of the webhook's channel.
+	ThreadID string `json:"thread_id,omitempty"`
+	// ThreadName starts a post with that name when the webhook belongs to a
+	// forum channel, webhooks can not start threads in text channels.
+	ThreadName string `json:"thread_name,omitempty"`
+	// Username and AvatarURL override the name and avatar of the webhook,
+	// so several senders can share one.
+	Username  string `json:"username,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	// AllowedMentions limits who the text pings, Discord's default pings
+	// every user, role and @everyone mentioned.
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+	// TTS reads the message aloud to the members viewing the channel.
+	TTS bool `json:"tts,omitempty"`
+	// Flags are FlagSuppressEmbeds and FlagSuppressNotifications.
+	Flags int `json:"flags,omitempty"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the webhook or bot token in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Token)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
+// Deprecated: Discord does not answer with it, sends return a Message.
 type Resp struct {
 	Ok    bool   `json:"ok"`
 	Error string `json:"error"`
 }
 
 type Webhook struct {
-	Content string `json:"content"`
+	Content         string           `json:"content"`
+	Username        string           `json:"username,omitempty"`
+	AvatarURL       string           `json:"avatar_url,omitempty"`
+	TTS             bool             `json:"tts,omitempty"`
+	Flags           int              `json:"flags,omitempty"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+	ThreadName      string           `json:"thread_name,omitempty"`
+	Embeds          []*Embed         `json:"embeds,omitempty"`
+	Components      []component      `json:"components,omitempty"`
+}
+
+// Message flags a webhook may set.
+const (
+	// FlagSuppressEmbeds hides the previews of the links in the message.
+	FlagSuppressEmbeds = 1 << 2
+	// FlagSuppressNotifications posts the message silently.
+	FlagSuppressNotifications = 1 << 12
+)
+
+// Mention types of AllowedMentions.Parse.
+const (
+	MentionUsers    = "users"
+	MentionRoles    = "roles"
+	MentionEveryone = "everyone"
+)
+
+// AllowedMentions limits the mentions in a message that ping.
+// https://discord.com/developers/docs/resources/message#allowed-mentions-object
+type AllowedMentions struct {
+	// Parse are the mention types that ping wherever they are in the text.
+	Parse []string `json:"parse"`
+	// Roles and Users ping only these ids, their types must not be in Parse.
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
+}
+
+// NoMentions pings nobody, the mentions are still shown.
+func NoMentions() *AllowedMentions {
+	return &AllowedMentions{Parse: []string{}}
+}
+
+// webhook returns the message posting content with the identity and flags
+// of the options.
+func (c *client) webhook(content string) (*Webhook, error) {
+	if c.opt.Flags&^(FlagSuppressEmbeds|FlagSuppressNotifications) != 0 {
+		return nil, fmt.Errorf("invalid webhook flags %d", c.opt.Flags)
+	}
+	return &Webhook{
+		Content:         content,
+		Username:        c.opt.Username,
+		AvatarURL:       c.opt.AvatarURL,
+		TTS:             c.opt.TTS,
+		Flags:           c.opt.Flags,
+		AllowedMentions: c.opt.AllowedMentions,
+		ThreadName:      c.opt.ThreadName,
+	}, nil
+}
+
+// Message is the part of a message Discord returns for a webhook post with
+// wait=true and for edits.
+type Message struct {
+	ID        string    `json:"id"`
+	ChannelID string    `json:"channel_id"`
+	Timestamp time.Time `json:"timestamp"`
+	// EditedTimestamp is set once the message is edited.
+	EditedTimestamp *time.Time `json:"edited_timestamp"`
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns the id of the created message.
+// The receipt's ThreadID is the thread it was posted in, if any.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "discord", c.opt.Channel, start, err)
+	return r, err
+}
+
+// webhookURL returns the url of the webhook with the query the options need.
+func (c *client) webhookURL(query url.Values) string {
+	if c.opt.ThreadID != "" {
+		query.Set("thread_id", c.opt.ThreadID)
+	}
+	apiURL := ApiURL + c.opt.Channel + "/" + c.opt.Token
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+	return apiURL
+}
+
+func (c *client) send(ctx context.Context, text string) (*receipt.Receipt, error) {
 	if "" == c.opt.Token {
-		return errors.New("missing token")
+		return nil, errors.New("missing token")
 	}
 
-	if "" == c.opt.Channel {
-		return errors.New("missing channel")
+	if "" == text && len(c.opt.Embeds) == 0 {
+		return nil, errors.New("missing message")
 	}
+	c.opt.Text = text
 
-	if "" == message {
-		return errors.New("missing message")
+	if c.opt.Bot {
+		return c.sendBot(ctx, text)
 	}
-	c.opt.Text = message
 
-	whMsg := &Webhook{
-		Content: c.opt.Text,
+	if "" == c.opt.Channel {
+		return nil, errors.New("missing channel")
 	}
 
-	inrec, err := json.Marshal(whMsg)
+	content, embeds, err := c.content(text)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	params := &req.Param{}
-	err = json.Unmarshal(inrec, params)
+	whMsg, err := c.webhook(content)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	whMsg.Embeds = embeds
 
-	apiURL := ApiURL + c.opt.Channel + "/" + c.opt.Token
-	resp, err := req.Post(apiURL, *params)
+	// wait=true makes Discord return the message rather than no content
+	query := url.Values{"wait": {"true"}}
+	if len(c.opt.Actions) > 0 {
+		components, err := actionRows(c.opt.Actions)
+		if err != nil {
+			return nil, err
+		}
+		whMsg.Components = components
+		query.Set("with_components", "true")
+	}
+
+	resp, err := req.Post(c.webhookURL(query), req.BodyJSON(whMsg), ctx, tracing.HTTPClient)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err := checkResp(resp.Response()); err != nil {
+		return nil, err
+	}
+	m := &Message{}
+	if err := resp.ToJSON(m); err != nil {
+		return nil, err
 	}
 
-	r := &Resp{}
-	return resp.ToJSON(r)
+	r := &receipt.Receipt{
+		Platform:   "discord",
+		Channel:    m.ChannelID,
+		MessageIDs: []string{m.ID},
+		ThreadID:   c.opt.ThreadID,
+		SentAt:     m.Timestamp,
+		Raw:        receipt.JSON(resp.Bytes()),
+	}
+	if r.SentAt.IsZero() {
+		r.SentAt = time.Now()
+	}
+	if r.ThreadID == "" && c.opt.ThreadName != "" {
+		// the post is a thread of its own
+		r.ThreadID = m.ChannelID
+	}
+	return r, nil
 }
@@ -0,0 +1,49 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+)
+
+func TestWebhookIdentity(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.Unmarshal([]byte(r.FormValue("payload_json")), &got)
+		w.Write([]byte(`{"id":"42","channel_id":"7","timestamp":"2024-05-01T10:00:00+00:00"}`))
+	}))
+	defer srv.Close()
+	defer func(url string) { ApiURL = url }(ApiURL)
+	ApiURL = srv.URL + "/"
+
+	c := New(Options{
+		Token:           "token",
+		Channel:         "123",
+		Username:        "deploy-bot",
+		AvatarURL:       "https://example.com/bot.png",
+		AllowedMentions: NoMentions(),
+		Flags:           FlagSuppressNotifications,
+	})
+	files := []*attachment.Attachment{attachment.New("log.txt", "text/plain", []byte("ok"))}
+	r, err := c.SendAttachmentsWithReceipt(context.Background(), "@everyone deployed", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Channel != "7" || r.MessageID() != "42" {
+		t.Errorf("receipt %+v", r)
+	}
+	mentions, _ := got["allowed_mentions"].(map[string]interface{})
+	if got["username"] != "deploy-bot" || got["avatar_url"] != "https://example.com/bot.png" ||
+		got["flags"] != float64(FlagSuppressNotifications) || mentions == nil || len(mentions["parse"].([]interface{})) != 0 {
+		t.Errorf("payload %v", got)
+	}
+
+	c = New(Options{Token: "token", Channel: "123", Flags: 1})
+	if err := c.SendAttachments(context.Background(), "x", files); err == nil {
+		t.Error("sent with a flag webhooks can not set")
+	}
+}
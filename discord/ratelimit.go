@@ -0,0 +1,196 @@
+package discord
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRateLimitRetries is how many times a request answered with 429 is
+	// sent again.
+	maxRateLimitRetries = 3
+	// maxLimiters is how many tokens have their limits followed, the least
+	// recently used is forgotten beyond.
+	maxLimiters = 64
+	// maxBuckets is how many buckets a limiter keeps before dropping those
+	// that were reset.
+	maxBuckets = 1024
+)
+
+// rateLimiter follows the rate limit buckets Discord reports for the routes
+// of one token, and its global limit.
+// https://discord.com/developers/docs/topics/rate-limits
+type rateLimiter struct {
+	mu      sync.Mutex
+	routes  map[string]string // route to bucket hash
+	buckets map[string]*bucket
+	global  time.Time // no request before
+	used    time.Time
+}
+
+type bucket struct {
+	limit     int
+	remaining int
+	reset     time.Time
+	window    time.Duration // between resets
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[[sha256.Size]byte]*rateLimiter{}
+)
+
+// limiterFor returns the rateLimiter of token, shared by the clients created
+// for each message.
+func limiterFor(token string) *rateLimiter {
+	key := sha256.Sum256([]byte(token))
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+	l, ok := limiters[key]
+	if !ok {
+		if len(limiters) >= maxLimiters {
+			var oldest [sha256.Size]byte
+			var oldestUsed time.Time
+			for k, v := range limiters {
+				if oldestUsed.IsZero() || v.used.Before(oldestUsed) {
+					oldest, oldestUsed = k, v.used
+				}
+			}
+			delete(limiters, oldest)
+		}
+		l = &rateLimiter{routes: make(map[string]string), buckets: make(map[string]*bucket)}
+		limiters[key] = l
+	}
+	l.used = time.Now()
+	return l
+}
+
+// route returns the rate limit route of a request to path, below the api
+// url, and its major parameter: routes of different channels have separate
+// limits even when they share a bucket hash.
+func route(method, path string) (string, string) {
+	parts := strings.Split(strings.SplitN(path, "?", 2)[0], "/")
+	major := ""
+	if len(parts) > 1 && (parts[0] == "channels" || parts[0] == "webhooks") {
+		major = parts[1]
+		if parts[0] == "webhooks" && len(parts) > 2 {
+			major += "/" + parts[2]
+		}
+	}
+	// message ids do not have limits of their own
+	for i := 1; i < len(parts); i++ {
+		if parts[i-1] == "messages" {
+			parts[i] = "{id}"
+		}
+	}
+	return method + " " + strings.Join(parts, "/"), major
+}
+
+func (l *rateLimiter) bucketKey(route, major string) string {
+	if hash, ok := l.routes[route]; ok {
+		return hash + ":" + major
+	}
+	return route + ":" + major
+}
+
+// wait blocks until a request to route may be sent, and counts it against
+// the remaining requests of its bucket so concurrent requests do not burst
+// past the limit before an answer reports it.
+func (l *rateLimiter) wait(ctx context.Context, route, major string) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		until := l.global
+		b := l.buckets[l.bucketKey(route, major)]
+		if b != nil {
+			if b.remaining <= 0 && !b.reset.After(now) && b.limit > 0 && b.window > 0 {
+				// a new window, until an answer tells its actual reset
+				b.remaining, b.reset = b.limit, now.Add(b.window)
+			}
+			if b.remaining <= 0 && b.reset.After(until) {
+				until = b.reset
+			}
+		}
+		if !until.After(now) {
+			if b != nil {
+				b.remaining--
+			}
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		t := time.NewTimer(until.Sub(now))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// update records the limits reported by resp, whose body is body, and
+// reports whether it was rate limited so the request should be sent again.
+func (l *rateLimiter) update(route, major string, resp *http.Response, body []byte) bool {
+	h := resp.Header
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if hash := h.Get("X-RateLimit-Bucket"); hash != "" {
+		l.routes[route] = hash
+	}
+	key := l.bucketKey(route, major)
+	if remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining")); err == nil {
+		if len(l.buckets) >= maxBuckets {
+			l.prune()
+		}
+		b := &bucket{remaining: remaining, reset: time.Now()}
+		b.limit, _ = strconv.Atoi(h.Get("X-RateLimit-Limit"))
+		if after, err := strconv.ParseFloat(h.Get("X-RateLimit-Reset-After"), 64); err == nil {
+			b.window = seconds(after)
+			b.reset = b.reset.Add(b.window)
+		}
+		l.buckets[key] = b
+	}
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	var limited struct {
+		RetryAfter float64 `json:"retry_after"`
+		Global     bool    `json:"global"`
+	}
+	json.Unmarshal(body, &limited)
+	if limited.RetryAfter == 0 {
+		limited.RetryAfter, _ = strconv.ParseFloat(h.Get("Retry-After"), 64)
+	}
+	until := time.Now().Add(seconds(limited.RetryAfter))
+	if limited.Global || h.Get("X-RateLimit-Global") == "true" || h.Get("X-RateLimit-Scope") == "global" {
+		l.global = until
+	} else {
+		l.buckets[key] = &bucket{remaining: 0, reset: until}
+	}
+	return true
+}
+
+// prune drops the buckets that were reset, they are learned again from the
+// next answers.
+func (l *rateLimiter) prune() {
+	now := time.Now()
+	for key, b := range l.buckets {
+		if !b.reset.After(now) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,182 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// webhookEdit replaces every part of a message, empty lists remove the old
+// embeds and buttons.
+type webhookEdit struct {
+	Content         string           `json:"content"`
+	Embeds          []*Embed         `json:"embeds"`
+	Components      []component      `json:"components"`
+	AllowedMentions *AllowedMentions `json:"allowed_mentions,omitempty"`
+}
+
+// Update replaces the message in r by editing it through the webhook that
+// sent it, or as the bot in bot mode.
+func (c *client) Update(ctx context.Context, r *receipt.Receipt, message string) error {
+	if r == nil {
+		r = &receipt.Receipt{}
+	}
+	start := time.Now()
+	_, err := c.edit(ctx, r.Channel, r.MessageID(), r.ThreadID, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "discord", c.opt.Channel, start, err)
+	return err
+}
+
+// Edit replaces the content, embeds and buttons of the message messageID,
+// sent by the webhook in the thread of the options if any, or by the bot to
+// the channel of the options, and returns the edited message.
+func (c *client) Edit(ctx context.Context, messageID, message string) (*Message, error) {
+	start := time.Now()
+	m, err := c.edit(ctx, "", messageID, c.opt.ThreadID, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "discord", c.opt.Channel, start, err)
+	return m, err
+}
+
+// Delete deletes the message messageID, sent by the webhook in the thread
+// of the options if any, or by the bot to the channel of the options.
+func (c *client) Delete(ctx context.Context, messageID string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.delete(ctx, messageID))
+	logger.Delivery(ctx, c.opt.Logger, "discord", c.opt.Channel, start, err)
+	return err
+}
+
+// messageURL returns the url of a message sent by the webhook.
+func (c *client) messageURL(messageID string, query url.Values) string {
+	apiURL := ApiURL + c.opt.Channel + "/" + c.opt.Token + "/messages/" + url.PathEscape(messageID)
+	if len(query) > 0 {
+		apiURL += "?" + query.Encode()
+	}
+	return apiURL
+}
+
+func (c *client) checkMessage(messageID string) error {
+	if "" == c.opt.Token {
+		return errors.New("missing token")
+	}
+
+	if "" == c.opt.Channel && !c.opt.Bot {
+		return errors.New("missing channel")
+	}
+
+	if "" == messageID {
+		return errors.New("missing message id")
+	}
+	return nil
+}
+
+// edit edits the message in channel, the channel of the options when empty,
+// in bot mode and in the thread threadID through the webhook.
+func (c *client) edit(ctx context.Context, channel, messageID, threadID, message string) (*Message, error) {
+	if err := c.checkMessage(messageID); err != nil {
+		return nil, err
+	}
+
+	if "" == message && len(c.opt.Embeds) == 0 {
+		return nil, errors.New("missing message")
+	}
+
+	content, embeds, err := c.content(message)
+	if err != nil {
+		return nil, err
+	}
+	edit := &webhookEdit{
+		Content:         content,
+		Embeds:          []*Embed{},
+		Components:      []component{},
+		AllowedMentions: c.opt.AllowedMentions,
+	}
+	if embeds != nil {
+		edit.Embeds = embeds
+	}
+	query := url.Values{}
+	if len(c.opt.Actions) > 0 {
+		components, err := actionRows(c.opt.Actions)
+		if err != nil {
+			return nil, err
+		}
+		edit.Components = components
+		query.Set("with_components", "true")
+	}
+	if threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+	body, err := json.Marshal(edit)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.opt.Bot {
+		if channel == "" {
+			if channel, err = c.botChannel(ctx); err != nil {
+				return nil, err
+			}
+		}
+		m := &Message{}
+		if err := c.do(ctx, http.MethodPatch, "channels/"+channel+"/messages/"+url.PathEscape(messageID), "application/json", body, m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPatch, c.messageURL(messageID, query), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResp(resp); err != nil {
+		return nil, err
+	}
+	m := &Message{}
+	if err := json.NewDecoder(resp.Body).Decode(m); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *client) delete(ctx context.Context, messageID string) error {
+	if err := c.checkMessage(messageID); err != nil {
+		return err
+	}
+	if c.opt.Bot {
+		channel, err := c.botChannel(ctx)
+		if err != nil {
+			return err
+		}
+		return c.do(ctx, http.MethodDelete, "channels/"+channel+"/messages/"+url.PathEscape(messageID), "", nil, nil)
+	}
+	query := url.Values{}
+	if c.opt.ThreadID != "" {
+		query.Set("thread_id", c.opt.ThreadID)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.messageURL(messageID, query), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResp(resp)
+}
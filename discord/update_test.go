@@ -0,0 +1,46 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEditDelete(t *testing.T) {
+	var calls []string
+	var edit webhookEdit
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.RequestURI())
+		if r.Method == http.MethodPatch {
+			json.NewDecoder(r.Body).Decode(&edit)
+			w.Write([]byte(`{"id":"42","channel_id":"7","timestamp":"2024-05-01T10:00:00+00:00","edited_timestamp":"2024-05-01T10:05:00+00:00"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	defer func(url string) { ApiURL = url }(ApiURL)
+	ApiURL = srv.URL + "/"
+
+	ctx := context.Background()
+	c := New(Options{Token: "token", Channel: "123", ThreadID: "9"})
+	m, err := c.Edit(ctx, "42", "deploy done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.ID != "42" || m.ChannelID != "7" || m.EditedTimestamp == nil || edit.Content != "deploy done" {
+		t.Errorf("edited %+v with %+v", m, edit)
+	}
+	if err := c.Delete(ctx, "42"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"PATCH /123/token/messages/42?thread_id=9", "DELETE /123/token/messages/42?thread_id=9"}
+	if len(calls) != 2 || calls[0] != want[0] || calls[1] != want[1] {
+		t.Errorf("calls %q, want %q", calls, want)
+	}
+	if err := c.Delete(ctx, ""); err == nil {
+		t.Error("deleted without a message id")
+	}
+}
@@ -1,10 +1,23 @@
 package email
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/smtp"
 	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Options struct {
@@ -12,6 +25,33 @@ type Options struct {
 	User     string `json:"user"`
 	Password string `json:"password"`
 	Host     string `json:"host"`
+	// Priority sets the X-Priority header, from 1 (highest) to 5.
+	Priority int `json:"priority"`
+	// InReplyTo is the Message-ID of an earlier email, the message joins
+	// its conversation.
+	InReplyTo string `json:"in_reply_to"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the SMTP password in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Password = redact.Secret(o.Password)
+	return redact.Printable{V: options(o)}
 }
 
 type Info struct {
@@ -20,20 +60,54 @@ type Info struct {
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Password)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns its Message-ID, which later
+// emails reply to with InReplyTo.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	return c.SendAttachmentsWithReceipt(ctx, message, nil)
+}
+
+// SendAttachments sends message with files attached as MIME parts.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the Message-ID
+// like SendWithReceipt.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "email", c.opt.ToEmail, start, err)
+	return r, err
+}
+
+func (c *client) send(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
 	if "" == c.opt.ToEmail {
-		return errors.New("missing email address")
+		return nil, errors.New("missing email address")
 	}
 
 	if "" == message {
-		return errors.New("missing message")
+		return nil, errors.New("missing message")
 	}
 
 	var subject string
@@ -62,11 +136,78 @@ func (c *client) Send(message string) error {
 
 	body := content
 
-	if err := SendToMail(user, password, host, subject, body, mailType, replyToAddress, to, cc, bcc); err != nil {
-		return errors.New("send email error: " + err.Error())
-	} else {
+	// the Message-ID is set here rather than by the server so that
+	// follow-ups can refer to it
+	messageID := NewMessageID(user)
+	headers := append(mailHeaders(user, subject, replyToAddress, to, cc), "Message-ID: "+messageID)
+	headers = append(headers, ThreadHeaders(c.opt.InReplyTo)...)
+	headers = append(headers, PriorityHeaders(c.opt.Priority)...)
+
+	_, span := tracing.Start(ctx, "smtp SendMail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("net.peer.name", host)),
+	)
+	err = sendMIME(user, password, host, headers, mailType, body, to, cc, bcc, files)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, errors.New("send email error: " + err.Error())
+	}
+	r := &receipt.Receipt{
+		Platform:   "email",
+		Channel:    c.opt.ToEmail,
+		MessageIDs: []string{messageID},
+		ThreadID:   c.opt.InReplyTo,
+		SentAt:     time.Now(),
+	}
+	if r.ThreadID == "" {
+		r.ThreadID = messageID
+	}
+	return r, nil
+}
+
+// NewMessageID returns a unique Message-ID in the domain of the from
+// address.
+func NewMessageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 && i < len(from)-1 {
+		domain = strings.TrimSuffix(from[i+1:], ">")
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// ThreadHeaders returns the In-Reply-To and References headers of a reply
+// to the email with Message-ID inReplyTo, none when it is empty.
+func ThreadHeaders(inReplyTo string) []string {
+	if inReplyTo == "" {
+		return nil
+	}
+	return []string{
+		"In-Reply-To: " + inReplyTo,
+		"References: " + inReplyTo,
+	}
+}
+
+// PriorityHeaders returns the X-Priority and Importance headers of an
+// email with priority from 1 (highest) to 5 (lowest), none for 0.
+func PriorityHeaders(priority int) []string {
+	if priority <= 0 {
 		return nil
 	}
+	if priority > 5 {
+		priority = 5
+	}
+	importance := "Normal"
+	if priority < 3 {
+		importance = "High"
+	} else if priority > 3 {
+		importance = "Low"
+	}
+	return []string{
+		fmt.Sprintf("X-Priority: %d", priority),
+		"Importance: " + importance,
+	}
 }
 
 func MergeSlice(s1 []string, s2 []string) []string {
@@ -96,3 +237,40 @@ func SendToMail(user, password, host, subject, body, mailtype, replyToAddress st
 	err := smtp.SendMail(host, auth, user, send_to, msg)
 	return err
 }
+
+// SendToMailWithAttachments is SendToMail for a multipart message with
+// files attached.
+func SendToMailWithAttachments(user, password, host, subject, body, mailtype, replyToAddress string, to, cc, bcc []string, files []*attachment.Attachment) error {
+	headers := mailHeaders(user, subject, replyToAddress, to, cc)
+	return sendMIME(user, password, host, headers, mailtype, body, to, cc, bcc, files)
+}
+
+func mailHeaders(user, subject, replyToAddress string, to, cc []string) []string {
+	headers := []string{
+		"To: " + strings.Join(to, ";"),
+		"From: " + user,
+		"Subject: " + subject,
+		"Reply-To: " + replyToAddress,
+	}
+	if len(cc) > 0 {
+		headers = append(headers, "Cc: "+strings.Join(cc, ";"))
+	}
+	return headers
+}
+
+func sendMIME(user, password, host string, headers []string, mailtype, body string, to, cc, bcc []string, files []*attachment.Attachment) error {
+	hp := strings.Split(host, ":")
+	auth := smtp.PlainAuth("", user, password, hp[0])
+	bodyType := "text/plain"
+	if mailtype == "html" {
+		bodyType = "text/html"
+	}
+	msg, err := attachment.MIME(headers, bodyType, body, files)
+	if err != nil {
+		return err
+	}
+
+	send_to := MergeSlice(to, cc)
+	send_to = MergeSlice(send_to, bcc)
+	return smtp.SendMail(host, auth, user, send_to, msg)
+}
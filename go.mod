@@ -1,6 +1,6 @@
 module github.com/ChainbotAI/go-notify
 
-go 1.15
+go 1.21
 
 require (
 	github.com/ChainbotAI/base-gokit v1.3.19-0.20241028084326-07fe8e3e932f
@@ -9,5 +9,15 @@ require (
 	github.com/imroc/req v0.3.2
 	github.com/sirupsen/logrus v1.9.3
 	github.com/sourcegraph/conc v0.3.0
+	go.opentelemetry.io/otel v1.16.0
+	go.opentelemetry.io/otel/trace v1.16.0
 	gopkg.in/telebot.v3 v3.3.8
 )
+
+require (
+	github.com/go-logr/logr v1.2.4 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	go.opentelemetry.io/otel/metric v1.16.0 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+)
@@ -0,0 +1,78 @@
+// Package tracing holds the OpenTelemetry helpers shared by the notify
+// providers. Spans are created with the globally registered tracer provider,
+// so nothing is recorded unless the application has configured one.
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/ChainbotAI/go-notify"
+
+// HTTPClient is used by the providers for their API calls, every request
+// made through it is recorded as a child span of the request context.
+var HTTPClient = &http.Client{Transport: &Transport{}}
+
+// Start starts a span named name as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport is an http.RoundTripper that wraps every request in a client span.
+// The response status code is recorded on that span and on the calling span,
+// so the delivery span shows the provider's answer without drilling down.
+//
+// Only the method and host are recorded: several providers carry credentials
+// in the URL path or query.
+type Transport struct {
+	Base http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	parent := trace.SpanFromContext(r.Context())
+	ctx, span := Start(r.Context(), "HTTP "+r.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("net.peer.name", r.URL.Hostname()),
+		),
+	)
+
+	resp, err := base.RoundTrip(r.WithContext(ctx))
+	if err != nil {
+		End(span, err)
+		return nil, err
+	}
+
+	status := attribute.Int("http.status_code", resp.StatusCode)
+	span.SetAttributes(status)
+	parent.SetAttributes(status)
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	span.End()
+	return resp, nil
+}
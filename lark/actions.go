@@ -0,0 +1,125 @@
+package lark
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// SendCard sends markdown text as a message card, with Actions as buttons.
+func (c *client) SendCard(ctx context.Context, title, markdown string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.sendCard(ctx, title, markdown, "markdown"))
+	logger.Delivery(ctx, c.opt.Logger, "lark", c.opt.Token, start, err)
+	return err
+}
+
+// sendCard sends text in an element of kind tag, "markdown" or "plain_text".
+func (c *client) sendCard(ctx context.Context, title, text, tag string) error {
+	if "" == text {
+		return errors.New("missing message")
+	}
+
+	var elements []map[string]interface{}
+	if tag == "markdown" {
+		elements = append(elements, map[string]interface{}{"tag": "markdown", "content": text})
+	} else {
+		elements = append(elements, map[string]interface{}{
+			"tag":  "div",
+			"text": map[string]string{"tag": tag, "content": text},
+		})
+	}
+	if len(c.opt.Actions) > 0 {
+		elements = append(elements, map[string]interface{}{
+			"tag":     "action",
+			"actions": cardButtons(c.opt.Actions),
+		})
+	}
+	card := map[string]interface{}{
+		"config":   map[string]bool{"wide_screen_mode": true},
+		"elements": elements,
+	}
+	if title != "" {
+		card["header"] = map[string]interface{}{
+			"title": map[string]string{"tag": "plain_text", "content": title},
+		}
+	}
+
+	rj, err := json.Marshal(map[string]interface{}{
+		"msg_type": "interactive",
+		"card":     card,
+	})
+	if err != nil {
+		return err
+	}
+	r := &apiResp{}
+	if err := c.post(ctx, c.opt.Token, "", bytes.NewReader(rj), "application/json", r); err != nil {
+		return err
+	}
+	if r.Code != 0 {
+		return fmt.Errorf("lark error %d: %s", r.Code, r.Msg)
+	}
+	return nil
+}
+
+func cardButtons(actions []action.Action) []map[string]interface{} {
+	buttons := make([]map[string]interface{}, 0, len(actions))
+	for _, a := range actions {
+		button := map[string]interface{}{
+			"tag":  "button",
+			"text": map[string]string{"tag": "plain_text", "content": a.Label},
+			"type": "default",
+		}
+		if a.Style != action.StyleDefault {
+			button["type"] = string(a.Style)
+		}
+		if a.IsLink() {
+			button["url"] = a.URL
+		} else {
+			button["value"] = map[string]string{"action_id": a.ID, "value": a.Value}
+		}
+		buttons = append(buttons, button)
+	}
+	return buttons
+}
+
+// MaxRequestAge is how old a signed callback from Lark may be.
+const MaxRequestAge = 5 * time.Minute
+
+// VerifyCallback checks the X-Lark-Signature of a message card callback
+// against the app's verification token, and that it was signed within
+// MaxRequestAge so it can not be replayed later.
+// https://open.larksuite.com/document/ukTMukTMukTM/uYzM3QjL2MzN04iNzcDN/configuring-card-callbacks
+func VerifyCallback(verificationToken string, header http.Header, body []byte) error {
+	if verificationToken == "" {
+		return errors.New("missing verification token")
+	}
+	sec, err := strconv.ParseInt(header.Get("X-Lark-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return errors.New("missing request timestamp")
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return errors.New("request timestamp too old")
+	}
+	h := sha1.New()
+	h.Write([]byte(header.Get("X-Lark-Request-Timestamp")))
+	h.Write([]byte(header.Get("X-Lark-Request-Nonce")))
+	h.Write([]byte(verificationToken))
+	h.Write(body)
+	want := hex.EncodeToString(h.Sum(nil))
+	if subtle.ConstantTimeCompare([]byte(want), []byte(header.Get("X-Lark-Signature"))) != 1 {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
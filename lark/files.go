@@ -0,0 +1,191 @@
+package lark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+type apiResp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type tokenResp struct {
+	apiResp
+	TenantAccessToken string `json:"tenant_access_token"`
+}
+
+type imageResp struct {
+	apiResp
+	Data struct {
+		ImageKey string `json:"image_key"`
+	} `json:"data"`
+}
+
+// SendAttachments sends message, then each image as an image message. The
+// images are uploaded with the app credentials, webhooks can not send other
+// files.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning when the images
+// were taken, webhook answers carry no message ids.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	err := c.redactor.Error(c.sendAttachments(ctx, message, files))
+	logger.Delivery(ctx, c.opt.Logger, "lark", c.opt.Token, start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &receipt.Receipt{Platform: "lark", SentAt: time.Now()}, nil
+}
+
+func (c *client) sendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	if len(files) == 0 {
+		return errors.New("missing attachment")
+	}
+	for _, f := range files {
+		if !f.IsImage() {
+			return fmt.Errorf("lark webhooks only accept image attachments, %s is %s", f.FileName(), f.Type())
+		}
+	}
+	if c.opt.AppID == "" || c.opt.AppSecret == "" {
+		return errors.New("missing app id or app secret to upload images")
+	}
+	base, err := c.openAPIURL()
+	if err != nil {
+		return err
+	}
+
+	token, err := c.tenantToken(ctx, base)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(files))
+	for _, f := range files {
+		key, err := c.uploadImage(ctx, base, token, f)
+		if err != nil {
+			return fmt.Errorf("upload %s: %w", f.FileName(), err)
+		}
+		keys = append(keys, key)
+	}
+
+	if message != "" {
+		if err := c.send(ctx, message); err != nil {
+			return err
+		}
+	}
+	for _, key := range keys {
+		rj, _ := json.Marshal(map[string]interface{}{
+			"msg_type": "image",
+			"content":  map[string]string{"image_key": key},
+		})
+		r := &apiResp{}
+		if err := c.post(ctx, c.opt.Token, "", bytes.NewReader(rj), "application/json", r); err != nil {
+			return err
+		}
+		if r.Code != 0 {
+			return fmt.Errorf("lark error %d: %s", r.Code, r.Msg)
+		}
+	}
+	return nil
+}
+
+// openAPIURL returns the Open API prefix on the host of the webhook, which
+// is either Feishu or Lark.
+func (c *client) openAPIURL() (string, error) {
+	u, err := url.Parse(c.opt.Token)
+	if err != nil || u.Host == "" {
+		return "", errors.New("invalid webhook url")
+	}
+	return u.Scheme + "://" + u.Host + "/open-apis/", nil
+}
+
+func (c *client) tenantToken(ctx context.Context, base string) (string, error) {
+	rj, _ := json.Marshal(map[string]string{
+		"app_id":     c.opt.AppID,
+		"app_secret": c.opt.AppSecret,
+	})
+	r := &tokenResp{}
+	err := c.post(ctx, base+"auth/v3/tenant_access_token/internal", "", bytes.NewReader(rj), "application/json", r)
+	if err != nil {
+		return "", err
+	}
+	if r.Code != 0 {
+		return "", fmt.Errorf("lark auth error %d: %s", r.Code, r.Msg)
+	}
+	c.redactor.Add(r.TenantAccessToken)
+	return r.TenantAccessToken, nil
+}
+
+func (c *client) uploadImage(ctx context.Context, base, token string, f *attachment.Attachment) (string, error) {
+	data, err := f.Bytes()
+	if err != nil {
+		return "", err
+	}
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("image_type", "message")
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="image"; filename=` + strconv.Quote(f.FileName())},
+		"Content-Type":        {f.Type()},
+	})
+	if err != nil {
+		return "", err
+	}
+	part.Write(data)
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	r := &imageResp{}
+	if err := c.post(ctx, base+"im/v1/images", token, &body, w.FormDataContentType(), r); err != nil {
+		return "", err
+	}
+	if r.Code != 0 {
+		return "", fmt.Errorf("lark error %d: %s", r.Code, r.Msg)
+	}
+	return r.Data.ImageKey, nil
+}
+
+// post sends body to apiURL, authenticated with token when set, and decodes
+// the response into v.
+func (c *client) post(ctx context.Context, apiURL, token string, body io.Reader, contentType string, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, body)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	rb, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(rb, v); err != nil {
+		return fmt.Errorf("lark server error: %s", string(rb))
+	}
+	return nil
+}
@@ -1,22 +1,61 @@
 package lark
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/imroc/req"
 )
 
 type Options struct {
 	Token   string `json:"token"`
 	Channel string `json:"channel"`
+	// AppID and AppSecret identify the app used to upload images, webhooks
+	// can only send images uploaded by an app.
+	AppID     string `json:"app_id"`
+	AppSecret string `json:"app_secret"`
+	// Actions turn the message into a card with buttons.
+	Actions []action.Action `json:"-"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the webhook url and app secret in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	o.AppSecret = redact.Secret(o.AppSecret)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Token, opt.AppSecret)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 type Resp struct {
@@ -38,10 +77,24 @@ type Text struct {
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.send(ctx, message))
+	logger.Delivery(ctx, c.opt.Logger, "lark", c.opt.Token, start, err)
+	return err
+}
+
+func (c *client) send(ctx context.Context, message string) error {
 
 	if "" == message {
 		return errors.New("missing message")
 	}
+	if len(c.opt.Actions) > 0 {
+		return c.sendCard(ctx, "", message, "plain_text")
+	}
 
 	t := Text{Text: message}
 	tj, _ := json.Marshal(t)
@@ -52,7 +105,45 @@ func (c *client) Send(message string) error {
 	rj, _ := json.Marshal(rd)
 
 	webhook := c.opt.Token
-	resp, err := req.Post(webhook, string(rj))
+	resp, err := req.Post(webhook, string(rj), ctx, tracing.HTTPClient)
+	if err != nil {
+		return err
+	}
+
+	r := &Resp{}
+	return resp.ToJSON(r)
+}
+
+// SendPost sends a "post" rich text message, content is the list of
+// paragraphs of the post.
+func (c *client) SendPost(ctx context.Context, title string, content interface{}) error {
+	start := time.Now()
+	err := c.redactor.Error(c.sendPost(ctx, title, content))
+	logger.Delivery(ctx, c.opt.Logger, "lark", c.opt.Token, start, err)
+	return err
+}
+
+func (c *client) sendPost(ctx context.Context, title string, content interface{}) error {
+	if content == nil {
+		return errors.New("missing message")
+	}
+
+	rj, err := json.Marshal(map[string]interface{}{
+		"msg_type": "post",
+		"content": map[string]interface{}{
+			"post": map[string]interface{}{
+				"en_us": map[string]interface{}{
+					"title":   title,
+					"content": content,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := req.Post(c.opt.Token, string(rj), ctx, tracing.HTTPClient)
 	if err != nil {
 		return err
 	}
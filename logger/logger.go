@@ -0,0 +1,114 @@
+// Package logger defines the structured logger used by notify and its
+// providers, with adapters for logrus and log/slog.
+package logger
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Field is a structured logging key/value pair.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F returns a Field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger is implemented by the adapters in this package, and can be
+// implemented to plug in any other logging library.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// Default returns l, or the logrus standard logger when l is nil.
+func Default(l Logger) Logger {
+	if l == nil {
+		return Logrus(logrus.StandardLogger())
+	}
+	return l
+}
+
+// Platform, Target, Duration, Attempt and Err are the fields logged for
+// every delivery attempt.
+func Platform(platform string) Field {
+	return F("platform", platform)
+}
+
+// Target masks all but the last four characters of target, which may be a
+// webhook url or an address.
+func Target(target string) Field {
+	return F("target", Mask(target))
+}
+
+func Duration(d time.Duration) Field {
+	return F("duration", d)
+}
+
+func Attempt(attempt int) Field {
+	return F("attempt", attempt)
+}
+
+func Err(err error) Field {
+	return F("error", err)
+}
+
+// Mask replaces all but the last four characters of s with asterisks.
+func Mask(s string) string {
+	const visible = 4
+	if len(s) <= visible*2 {
+		return "****"
+	}
+	return "****" + s[len(s)-visible:]
+}
+
+type attemptKey struct{}
+
+// WithAttempt returns ctx carrying the number of the delivery attempt, as
+// counted by retries, for Delivery to log.
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// AttemptFrom returns the attempt set by WithAttempt, 1 when there is none.
+func AttemptFrom(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// Delivery logs the outcome of a delivery that started at start.
+func Delivery(ctx context.Context, l Logger, platform, target string, start time.Time, err error) {
+	fields := []Field{
+		Platform(platform),
+		Target(target),
+		Duration(time.Since(start)),
+		Attempt(AttemptFrom(ctx)),
+	}
+	if err != nil {
+		Default(l).Warn("notify send failed", append(fields, Err(err))...)
+		return
+	}
+	Default(l).Debug("notify sent", fields...)
+}
+
+type nop struct{}
+
+// Nop returns a Logger that discards everything.
+func Nop() Logger {
+	return nop{}
+}
+
+func (nop) Debug(string, ...Field) {}
+func (nop) Info(string, ...Field)  {}
+func (nop) Warn(string, ...Field)  {}
+func (nop) Error(string, ...Field) {}
@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+type entry struct {
+	level, msg string
+	fields     map[string]interface{}
+}
+
+type recorder struct{ entries []entry }
+
+func (r *recorder) log(level, msg string, fields []Field) {
+	e := entry{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, f := range fields {
+		e.fields[f.Key] = f.Value
+	}
+	r.entries = append(r.entries, e)
+}
+
+func (r *recorder) Debug(msg string, fields ...Field) { r.log("debug", msg, fields) }
+func (r *recorder) Info(msg string, fields ...Field)  { r.log("info", msg, fields) }
+func (r *recorder) Warn(msg string, fields ...Field)  { r.log("warn", msg, fields) }
+func (r *recorder) Error(msg string, fields ...Field) { r.log("error", msg, fields) }
+
+func TestDelivery(t *testing.T) {
+	r := &recorder{}
+	start := time.Now()
+	Delivery(context.Background(), r, "slack", "https://hooks.slack.com/services/T/B/secret", start, nil)
+	Delivery(WithAttempt(context.Background(), 3), r, "slack", "C0123456", start, errors.New("rate limited"))
+	if len(r.entries) != 2 {
+		t.Fatalf("entries %+v", r.entries)
+	}
+	sent, failed := r.entries[0], r.entries[1]
+	if sent.level != "debug" || sent.fields["platform"] != "slack" || sent.fields["target"] != "****cret" || sent.fields["attempt"] != 1 {
+		t.Errorf("sent %+v", sent)
+	}
+	if failed.level != "warn" || failed.fields["attempt"] != 3 || failed.fields["error"].(error).Error() != "rate limited" {
+		t.Errorf("failed %+v", failed)
+	}
+}
+
+func TestLogrus(t *testing.T) {
+	l, hook := test.NewNullLogger()
+	l.SetLevel(logrus.DebugLevel)
+	log := Logrus(l)
+	log.Debug("d")
+	log.Info("i", F("k", 1))
+	log.Warn("w")
+	log.Error("e", Err(errors.New("boom")))
+
+	levels := []logrus.Level{logrus.DebugLevel, logrus.InfoLevel, logrus.WarnLevel, logrus.ErrorLevel}
+	if len(hook.Entries) != len(levels) {
+		t.Fatalf("entries %+v", hook.Entries)
+	}
+	for i, e := range hook.Entries {
+		if e.Level != levels[i] {
+			t.Errorf("%q logged at %s, want %s", e.Message, e.Level, levels[i])
+		}
+	}
+	if hook.Entries[1].Data["k"] != 1 || hook.Entries[3].Data["error"].(error).Error() != "boom" {
+		t.Errorf("fields %v %v", hook.Entries[1].Data, hook.Entries[3].Data)
+	}
+}
+
+func TestSlog(t *testing.T) {
+	var buf bytes.Buffer
+	log := Slog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	log.Debug("d")
+	log.Info("i", F("k", 1))
+	log.Warn("w")
+	log.Error("e", Err(errors.New("boom")))
+
+	levels := []string{"DEBUG", "INFO", "WARN", "ERROR"}
+	dec := json.NewDecoder(&buf)
+	for i, level := range levels {
+		var e map[string]interface{}
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if e["level"] != level {
+			t.Errorf("%v logged at %v, want %s", e["msg"], e["level"], level)
+		}
+		switch i {
+		case 1:
+			if e["k"] != float64(1) {
+				t.Errorf("fields %v", e)
+			}
+		case 3:
+			// errors are logged as their message
+			if e["error"] != "boom" {
+				t.Errorf("fields %v", e)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package logger
+
+import "github.com/sirupsen/logrus"
+
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// Logrus adapts a logrus logger or entry.
+func Logrus(l logrus.FieldLogger) Logger {
+	return &logrusLogger{l: l}
+}
+
+func (l *logrusLogger) with(fields []Field) logrus.FieldLogger {
+	if len(fields) == 0 {
+		return l.l
+	}
+	f := make(logrus.Fields, len(fields))
+	for _, field := range fields {
+		f[field.Key] = field.Value
+	}
+	return l.l.WithFields(f)
+}
+
+func (l *logrusLogger) Debug(msg string, fields ...Field) { l.with(fields).Debug(msg) }
+func (l *logrusLogger) Info(msg string, fields ...Field)  { l.with(fields).Info(msg) }
+func (l *logrusLogger) Warn(msg string, fields ...Field)  { l.with(fields).Warn(msg) }
+func (l *logrusLogger) Error(msg string, fields ...Field) { l.with(fields).Error(msg) }
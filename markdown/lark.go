@@ -0,0 +1,105 @@
+package markdown
+
+import "strconv"
+
+var larkCard = &dialect{
+	escape:     identity,
+	escapeCode: identity,
+	escapeURL:  identity,
+	strong:     wrap("**", "**"),
+	emphasis:   wrap("*", "*"),
+	code:       wrap("`", "`"),
+	codeBlock:  fence,
+	link:       func(url, label string) string { return "[" + label + "](" + url + ")" },
+	// card markdown has no headings
+	heading:   func(_ int, text string) string { return "**" + text + "**" },
+	paragraph: identity,
+	list:      plainList("- "),
+	lineBreak: "\n",
+	blockSep:  "\n\n",
+}
+
+// RenderLarkCard renders doc as the markdown of a Lark message card.
+func RenderLarkCard(doc *Node) string {
+	return larkCard.render(doc)
+}
+
+// RenderLarkPost renders doc as the content of a Lark "post" rich text
+// message: a list of paragraphs, each a list of elements.
+func RenderLarkPost(doc *Node) [][]map[string]interface{} {
+	var post [][]map[string]interface{}
+	for _, n := range doc.Children {
+		switch n.Kind {
+		case KindParagraph:
+			line := []map[string]interface{}{}
+			for _, el := range larkInline(n.Children, nil) {
+				if el == nil {
+					post = append(post, line)
+					line = []map[string]interface{}{}
+					continue
+				}
+				line = append(line, el)
+			}
+			post = append(post, line)
+		case KindHeading:
+			post = append(post, larkInline(n.Children, []string{"bold"}))
+		case KindList:
+			for i, item := range n.Children {
+				bullet := "• "
+				if n.Ordered {
+					bullet = strconv.Itoa(i+1) + ". "
+				}
+				line := []map[string]interface{}{larkText(bullet, nil)}
+				post = append(post, append(line, larkInline(item.Children, nil)...))
+			}
+		case KindCodeBlock:
+			post = append(post, []map[string]interface{}{{
+				"tag":      "code_block",
+				"language": n.Lang,
+				"text":     n.Text,
+			}})
+		}
+	}
+	return post
+}
+
+// larkInline flattens nodes into text and link elements, a nil element marks
+// a line break.
+func larkInline(nodes []*Node, style []string) []map[string]interface{} {
+	var els []map[string]interface{}
+	for _, n := range nodes {
+		switch n.Kind {
+		case KindText, KindCode:
+			els = append(els, larkText(n.Text, style))
+		case KindStrong:
+			els = append(els, larkInline(n.Children, withStyle(style, "bold"))...)
+		case KindEmphasis:
+			els = append(els, larkInline(n.Children, withStyle(style, "italic"))...)
+		case KindLink:
+			els = append(els, map[string]interface{}{
+				"tag":  "a",
+				"text": plain(n.Children),
+				"href": n.URL,
+			})
+		case KindLineBreak:
+			els = append(els, nil)
+		}
+	}
+	return els
+}
+
+func larkText(s string, style []string) map[string]interface{} {
+	el := map[string]interface{}{"tag": "text", "text": s}
+	if len(style) > 0 {
+		el["style"] = style
+	}
+	return el
+}
+
+func withStyle(style []string, s string) []string {
+	return append(append([]string(nil), style...), s)
+}
+
+func plain(nodes []*Node) string {
+	return text.inline(nodes)
+}
@@ -0,0 +1,86 @@
+package markdown
+
+import "testing"
+
+const sample = "# Disk full\n" +
+	"**db_1** is at *97%*, run `df -h`\n" +
+	"see [runbook](https://wiki.example.com/disk)\n" +
+	"\n" +
+	"- clean logs\n" +
+	"- resize volume\n" +
+	"\n" +
+	"```sh\n" +
+	"rm -rf /var/log/*.gz\n" +
+	"```"
+
+func TestRender(t *testing.T) {
+	doc := Parse(sample)
+	tests := []struct {
+		name   string
+		render func(*Node) string
+		want   string
+	}{
+		{"slack", RenderSlack, "*Disk full*\n\n" +
+			"*db_1* is at _97%_, run `df -h`\nsee <https://wiki.example.com/disk|runbook>\n\n" +
+			"• clean logs\n• resize volume\n\n" +
+			"```\nrm -rf /var/log/*.gz\n```"},
+		{"discord", RenderDiscord, "# Disk full\n\n" +
+			"**db\\_1** is at *97%*, run `df -h`\nsee [runbook](https://wiki.example.com/disk)\n\n" +
+			"- clean logs\n- resize volume\n\n" +
+			"```sh\nrm -rf /var/log/*.gz\n```"},
+		{"telegram", RenderTelegram, "*Disk full*\n\n" +
+			"*db\\_1* is at _97%_, run `df -h`\nsee [runbook](https://wiki.example.com/disk)\n\n" +
+			"• clean logs\n• resize volume\n\n" +
+			"```sh\nrm -rf /var/log/*.gz\n```"},
+		{"html", RenderHTML, "<h1>Disk full</h1>\n" +
+			"<p><strong>db_1</strong> is at <em>97%</em>, run <code>df -h</code><br>see <a href=\"https://wiki.example.com/disk\">runbook</a></p>\n" +
+			"<ul><li>clean logs</li><li>resize volume</li></ul>\n" +
+			"<pre><code>rm -rf /var/log/*.gz</code></pre>"},
+		{"text", RenderText, "Disk full\n\n" +
+			"db_1 is at 97%, run df -h\nsee runbook (https://wiki.example.com/disk)\n\n" +
+			"- clean logs\n- resize volume\n\n" +
+			"rm -rf /var/log/*.gz"},
+	}
+	for _, tt := range tests {
+		if got := tt.render(doc); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseLiterals(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"snake_case_name stays", "snake_case_name stays"},
+		{"unclosed **bold", "unclosed **bold"},
+		{Escape("*not* [a](link)"), "*not* [a](link)"},
+		{"2 * 3 * 4", "2 * 3 * 4"},
+	}
+	for _, tt := range tests {
+		if got := RenderText(Parse(tt.in)); got != tt.want {
+			t.Errorf("RenderText(Parse(%q)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRenderLinks(t *testing.T) {
+	doc := Parse("[docs](https://example.com/a|b>c) [mail](mailto:ops@example.com) " +
+		"[x](javascript:alert%281%29) [y](JavaScript:void) [z](data:text/html;base64,PHNjcmlwdD4=)")
+	tests := []struct {
+		name   string
+		render func(*Node) string
+		want   string
+	}{
+		{"slack", RenderSlack, "<https://example.com/a%7Cb&gt;c|docs> <mailto:ops@example.com|mail> " +
+			"<javascript:alert%281%29|x> <JavaScript:void|y> <data:text/html;base64,PHNjcmlwdD4=|z>"},
+		{"html", RenderHTML, `<p><a href="https://example.com/a|b&gt;c">docs</a> <a href="mailto:ops@example.com">mail</a> x y z</p>`},
+		{"telegram html", RenderTelegramHTML, `<a href="https://example.com/a|b&gt;c">docs</a> <a href="mailto:ops@example.com">mail</a> x y z`},
+	}
+	for _, tt := range tests {
+		if got := tt.render(doc); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package markdown parses the subset of markdown used in notifications and
+// renders it to the dialect of each platform.
+//
+// The supported subset is bold (**text**), italic (*text* or _text_), inline
+// code, fenced code blocks, links ([text](url)), headings (#) and ordered
+// or unordered lists. Anything else is kept as text.
+package markdown
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Kind int
+
+const (
+	KindDocument Kind = iota
+	KindParagraph
+	KindHeading
+	KindList
+	KindListItem
+	KindCodeBlock
+	KindText
+	KindStrong
+	KindEmphasis
+	KindCode
+	KindLink
+	KindLineBreak
+)
+
+// Node is a node of the parsed document.
+type Node struct {
+	Kind Kind
+	// Text is the content of text, code and code block nodes.
+	Text string
+	// URL is the target of a link.
+	URL string
+	// Level is the level of a heading, from 1 to 6.
+	Level int
+	// Ordered is set on numbered lists.
+	Ordered bool
+	// Lang is the language of a code block.
+	Lang     string
+	Children []*Node
+}
+
+// Parse parses s into a document.
+func Parse(s string) *Node {
+	doc := &Node{Kind: KindDocument}
+	lines := strings.Split(strings.Replace(s, "\r\n", "\n", -1), "\n")
+
+	var para []string
+	flush := func() {
+		if len(para) == 0 {
+			return
+		}
+		p := &Node{Kind: KindParagraph}
+		for i, line := range para {
+			if i > 0 {
+				p.Children = append(p.Children, &Node{Kind: KindLineBreak})
+			}
+			p.Children = append(p.Children, parseInline(line)...)
+		}
+		doc.Children = append(doc.Children, p)
+		para = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			block := &Node{Kind: KindCodeBlock, Lang: strings.TrimSpace(trimmed[3:])}
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			block.Text = strings.Join(code, "\n")
+			doc.Children = append(doc.Children, block)
+
+		case trimmed == "":
+			flush()
+
+		case headingLevel(trimmed) > 0:
+			flush()
+			level := headingLevel(trimmed)
+			doc.Children = append(doc.Children, &Node{
+				Kind:     KindHeading,
+				Level:    level,
+				Children: parseInline(strings.TrimSpace(trimmed[level:])),
+			})
+
+		case listMarker(trimmed) > 0:
+			flush()
+			_, ordered := orderedMarker(trimmed)
+			list := &Node{Kind: KindList, Ordered: ordered}
+			for ; i < len(lines); i++ {
+				item := strings.TrimSpace(lines[i])
+				n := listMarker(item)
+				if n == 0 {
+					break
+				}
+				if _, o := orderedMarker(item); o != ordered {
+					break
+				}
+				list.Children = append(list.Children, &Node{
+					Kind:     KindListItem,
+					Children: parseInline(strings.TrimSpace(item[n:])),
+				})
+			}
+			i--
+			doc.Children = append(doc.Children, list)
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flush()
+	return doc
+}
+
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// listMarker returns the length of the list marker at the start of line,
+// including the space after it, or 0.
+func listMarker(line string) int {
+	if len(line) > 1 && strings.IndexByte("-*+", line[0]) >= 0 && line[1] == ' ' {
+		return 2
+	}
+	n, _ := orderedMarker(line)
+	return n
+}
+
+func orderedMarker(line string) (int, bool) {
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i == 0 || i+1 >= len(line) || (line[i] != '.' && line[i] != ')') || line[i+1] != ' ' {
+		return 0, false
+	}
+	return i + 2, true
+}
+
+func parseInline(s string) []*Node {
+	var nodes []*Node
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, &Node{Kind: KindText, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && isPunct(s[i+1]):
+			text.WriteByte(s[i+1])
+			i += 2
+			continue
+
+		case c == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				flush()
+				nodes = append(nodes, &Node{Kind: KindCode, Text: s[i+1 : i+1+end]})
+				i += end + 2
+				continue
+			}
+
+		case (c == '*' || c == '_') && i+2 < len(s) && s[i+1] == c && !isSpace(s[i+2]):
+			delim := s[i : i+2]
+			if end := closing(s, i+2, delim); end > i+2 {
+				flush()
+				nodes = append(nodes, &Node{Kind: KindStrong, Children: parseInline(s[i+2 : end])})
+				i = end + 2
+				continue
+			}
+
+		case (c == '*' || c == '_') && i+1 < len(s) && !isSpace(s[i+1]):
+			// an underscore inside a word, as in snake_case, is not emphasis
+			if c == '_' && i > 0 && isWordByte(s[i-1]) {
+				break
+			}
+			if end := closing(s, i+1, string(c)); end > i+1 {
+				flush()
+				nodes = append(nodes, &Node{Kind: KindEmphasis, Children: parseInline(s[i+1 : end])})
+				i = end + 1
+				continue
+			}
+
+		case c == '[':
+			if label, url, n, ok := parseLink(s[i:]); ok {
+				flush()
+				nodes = append(nodes, &Node{Kind: KindLink, URL: url, Children: parseInline(label)})
+				i += n
+				continue
+			}
+		}
+		text.WriteByte(c)
+		i++
+	}
+	flush()
+	return nodes
+}
+
+// closing returns the index of the delimiter closing an emphasis that starts
+// at from, or -1.
+func closing(s string, from int, delim string) int {
+	for i := from; i+len(delim) <= len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '`':
+			if end := strings.IndexByte(s[i+1:], '`'); end >= 0 {
+				i += end + 1
+			}
+		case strings.HasPrefix(s[i:], delim) && !isSpace(s[i-1]):
+			// a single delimiter must not match half of a double one
+			if len(delim) == 1 && i+1 < len(s) && s[i+1] == delim[0] {
+				i++
+				continue
+			}
+			if delim[0] == '_' && i+len(delim) < len(s) && isWordByte(s[i+len(delim)]) {
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func parseLink(s string) (label, url string, n int, ok bool) {
+	end := strings.Index(s, "](")
+	if end < 0 {
+		return "", "", 0, false
+	}
+	close := strings.IndexByte(s[end+2:], ')')
+	if close < 0 {
+		return "", "", 0, false
+	}
+	url = s[end+2 : end+2+close]
+	if strings.ContainsAny(url, " \t") {
+		return "", "", 0, false
+	}
+	return s[1:end], url, end + 3 + close, true
+}
+
+func isPunct(c byte) bool {
+	return c < 0x80 && unicode.IsPunct(rune(c)) || c == '`' || c == '+' || c == '|' || c == '~' || c == '>' || c == '<' || c == '=' || c == '$' || c == '^'
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Escape escapes the characters of s that Parse would interpret, so text
+// can be embedded in a markdown message literally.
+func Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '`', '*', '_', '[', ']', '#', '-', '+':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
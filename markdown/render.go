@@ -0,0 +1,327 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// dialect describes how a platform writes each kind of node. Text and the
+// content of code are passed escaped.
+type dialect struct {
+	escape     func(string) string
+	escapeCode func(string) string
+	escapeURL  func(string) string
+	// safeLinks renders links to other schemes than http, https and mailto
+	// as their label, for html where they could run scripts.
+	safeLinks bool
+
+	strong    func(string) string
+	emphasis  func(string) string
+	code      func(string) string
+	codeBlock func(lang, text string) string
+	link      func(url, label string) string
+	heading   func(level int, text string) string
+	paragraph func(string) string
+	list      func(ordered bool, items []string) string
+
+	lineBreak string
+	blockSep  string
+}
+
+func (d *dialect) render(doc *Node) string {
+	blocks := make([]string, 0, len(doc.Children))
+	for _, n := range doc.Children {
+		switch n.Kind {
+		case KindParagraph:
+			blocks = append(blocks, d.paragraph(d.inline(n.Children)))
+		case KindHeading:
+			blocks = append(blocks, d.heading(n.Level, d.inline(n.Children)))
+		case KindList:
+			items := make([]string, 0, len(n.Children))
+			for _, item := range n.Children {
+				items = append(items, d.inline(item.Children))
+			}
+			blocks = append(blocks, d.list(n.Ordered, items))
+		case KindCodeBlock:
+			blocks = append(blocks, d.codeBlock(n.Lang, d.escapeCode(n.Text)))
+		}
+	}
+	return strings.Join(blocks, d.blockSep)
+}
+
+func (d *dialect) inline(nodes []*Node) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		switch n.Kind {
+		case KindText:
+			b.WriteString(d.escape(n.Text))
+		case KindStrong:
+			b.WriteString(d.strong(d.inline(n.Children)))
+		case KindEmphasis:
+			b.WriteString(d.emphasis(d.inline(n.Children)))
+		case KindCode:
+			b.WriteString(d.code(d.escapeCode(n.Text)))
+		case KindLink:
+			if d.safeLinks && !safeURL(n.URL) {
+				b.WriteString(d.inline(n.Children))
+				continue
+			}
+			b.WriteString(d.link(d.escapeURL(n.URL), d.inline(n.Children)))
+		case KindLineBreak:
+			b.WriteString(d.lineBreak)
+		}
+	}
+	return b.String()
+}
+
+func wrap(open, close string) func(string) string {
+	return func(s string) string { return open + s + close }
+}
+
+func identity(s string) string { return s }
+
+// safeURL reports whether u is an absolute http, https or mailto url.
+func safeURL(u string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(u))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func plainList(bullet string) func(bool, []string) string {
+	return func(ordered bool, items []string) string {
+		lines := make([]string, len(items))
+		for i, item := range items {
+			if ordered {
+				lines[i] = strconv.Itoa(i+1) + ". " + item
+			} else {
+				lines[i] = bullet + item
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+}
+
+func fence(lang, text string) string {
+	return "```" + lang + "\n" + text + "\n```"
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackURLEscaper also encodes the | that would end the url of a link.
+var slackURLEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "|", "%7C")
+
+var slack = &dialect{
+	escape:     slackEscaper.Replace,
+	escapeCode: slackEscaper.Replace,
+	escapeURL:  slackURLEscaper.Replace,
+	strong:     wrap("*", "*"),
+	emphasis:   wrap("_", "_"),
+	code:       wrap("`", "`"),
+	codeBlock:  func(_, text string) string { return fence("", text) },
+	link:       func(url, label string) string { return "<" + url + "|" + label + ">" },
+	heading:    func(_ int, text string) string { return "*" + text + "*" },
+	paragraph:  identity,
+	list:       plainList("• "),
+	lineBreak:  "\n",
+	blockSep:   "\n\n",
+}
+
+// RenderSlack renders doc as Slack mrkdwn.
+func RenderSlack(doc *Node) string {
+	return slack.render(doc)
+}
+
+var discordEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`,
+	">", `\>`, "#", `\#`, "[", `\[`, "]", `\]`,
+)
+
+var discord = &dialect{
+	escape:     discordEscaper.Replace,
+	escapeCode: identity,
+	escapeURL:  identity,
+	strong:     wrap("**", "**"),
+	emphasis:   wrap("*", "*"),
+	code: func(s string) string {
+		if strings.Contains(s, "`") {
+			return "`` " + s + " ``"
+		}
+		return "`" + s + "`"
+	},
+	codeBlock: fence,
+	link:      func(url, label string) string { return "[" + label + "](" + url + ")" },
+	heading: func(level int, text string) string {
+		if level > 3 {
+			return "**" + text + "**"
+		}
+		return strings.Repeat("#", level) + " " + text
+	},
+	paragraph: identity,
+	list:      plainList("- "),
+	lineBreak: "\n",
+	blockSep:  "\n\n",
+}
+
+// RenderDiscord renders doc as Discord markdown.
+func RenderDiscord(doc *Node) string {
+	return discord.render(doc)
+}
+
+// EscapeTelegram escapes s for Telegram's MarkdownV2 parse mode.
+func EscapeTelegram(s string) string {
+	return telegramEscaper.Replace(s)
+}
+
+var telegramEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+	"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+	"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+var telegram = &dialect{
+	escape:     telegramEscaper.Replace,
+	escapeCode: strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace,
+	escapeURL:  strings.NewReplacer(`\`, `\\`, ")", `\)`).Replace,
+	strong:     wrap("*", "*"),
+	emphasis:   wrap("_", "_"),
+	code:       wrap("`", "`"),
+	codeBlock:  fence,
+	link:       func(url, label string) string { return "[" + label + "](" + url + ")" },
+	heading:    func(_ int, text string) string { return "*" + text + "*" },
+	paragraph:  identity,
+	list: func(ordered bool, items []string) string {
+		lines := make([]string, len(items))
+		for i, item := range items {
+			if ordered {
+				lines[i] = strconv.Itoa(i+1) + `\. ` + item
+			} else {
+				lines[i] = "• " + item
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+	lineBreak: "\n",
+	blockSep:  "\n\n",
+}
+
+// RenderTelegram renders doc for Telegram's MarkdownV2 parse mode.
+func RenderTelegram(doc *Node) string {
+	return telegram.render(doc)
+}
+
+var telegramHTML = &dialect{
+	escape:     html.EscapeString,
+	escapeCode: html.EscapeString,
+	escapeURL:  html.EscapeString,
+	safeLinks:  true,
+	strong:     wrap("<b>", "</b>"),
+	emphasis:   wrap("<i>", "</i>"),
+	code:       wrap("<code>", "</code>"),
+	codeBlock: func(lang, text string) string {
+		if lang == "" {
+			return "<pre>" + text + "</pre>"
+		}
+		return `<pre><code class="language-` + html.EscapeString(lang) + `">` + text + "</code></pre>"
+	},
+	link:      func(url, label string) string { return `<a href="` + url + `">` + label + "</a>" },
+	heading:   func(_ int, text string) string { return "<b>" + text + "</b>" },
+	paragraph: identity,
+	list:      plainList("• "),
+	lineBreak: "\n",
+	blockSep:  "\n\n",
+}
+
+// RenderTelegramHTML renders doc for Telegram's HTML parse mode.
+func RenderTelegramHTML(doc *Node) string {
+	return telegramHTML.render(doc)
+}
+
+var dingtalk = &dialect{
+	escape:     identity,
+	escapeCode: identity,
+	escapeURL:  identity,
+	strong:     wrap("**", "**"),
+	emphasis:   wrap("*", "*"),
+	code:       wrap("`", "`"),
+	codeBlock: func(_, text string) string {
+		// dingtalk has no code blocks, quote the lines instead
+		return "> " + strings.Replace(text, "\n", "  \n> ", -1)
+	},
+	link:      func(url, label string) string { return "[" + label + "](" + url + ")" },
+	heading:   func(level int, text string) string { return strings.Repeat("#", level) + " " + text },
+	paragraph: identity,
+	list:      plainList("- "),
+	// dingtalk only breaks lines ending with two spaces
+	lineBreak: "  \n",
+	blockSep:  "\n\n",
+}
+
+// RenderDingTalk renders doc as DingTalk markdown.
+func RenderDingTalk(doc *Node) string {
+	return dingtalk.render(doc)
+}
+
+var htmlDialect = &dialect{
+	escape:     html.EscapeString,
+	escapeCode: html.EscapeString,
+	escapeURL:  html.EscapeString,
+	safeLinks:  true,
+	strong:     wrap("<strong>", "</strong>"),
+	emphasis:   wrap("<em>", "</em>"),
+	code:       wrap("<code>", "</code>"),
+	codeBlock:  func(_, text string) string { return "<pre><code>" + text + "</code></pre>" },
+	link:       func(url, label string) string { return `<a href="` + url + `">` + label + "</a>" },
+	heading: func(level int, text string) string {
+		h := "h" + strconv.Itoa(level)
+		return "<" + h + ">" + text + "</" + h + ">"
+	},
+	paragraph: wrap("<p>", "</p>"),
+	list: func(ordered bool, items []string) string {
+		tag := "ul"
+		if ordered {
+			tag = "ol"
+		}
+		return "<" + tag + "><li>" + strings.Join(items, "</li><li>") + "</li></" + tag + ">"
+	},
+	lineBreak: "<br>",
+	blockSep:  "\n",
+}
+
+// RenderHTML renders doc as html, for email bodies.
+func RenderHTML(doc *Node) string {
+	return htmlDialect.render(doc)
+}
+
+var text = &dialect{
+	escape:     identity,
+	escapeCode: identity,
+	escapeURL:  identity,
+	strong:     identity,
+	emphasis:   identity,
+	code:       identity,
+	codeBlock:  func(_, text string) string { return text },
+	link: func(url, label string) string {
+		if label == url {
+			return url
+		}
+		return label + " (" + url + ")"
+	},
+	heading:   func(_ int, text string) string { return text },
+	paragraph: identity,
+	list:      plainList("- "),
+	lineBreak: "\n",
+	blockSep:  "\n\n",
+}
+
+// RenderText renders doc as plain text, for platforms without formatting.
+func RenderText(doc *Node) string {
+	return text.render(doc)
+}
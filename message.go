@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"encoding/json"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/markdown"
+)
+
+// Attachment is a file sent with a message, see the attachment package.
+type Attachment = attachment.Attachment
+
+// Action is a button sent with a message, see the action and callback
+// packages.
+type Action = action.Action
+
+// Format is the markup of a message's text.
+type Format string
+
+const (
+	FormatText Format = ""
+	// FormatMarkdown messages are converted to each platform's own markup,
+	// see the markdown package for the supported syntax.
+	FormatMarkdown Format = "markdown"
+)
+
+// Message is a notification as it travels through the middleware chain.
+type Message struct {
+	// ID identifies the message in audit records, SendMessage generates
+	// one for its copy of the message when it is empty.
+	ID string
+
+	// Subject is the email subject, other platforms show it above the text.
+	Subject string
+	Text    string
+	Format  Format
+	// Severity sets the urgency the platforms show, see SeverityStyle.
+	// Config.Severity and Config.Priority apply when it is empty.
+	Severity Severity
+
+	// Template names a template to render Text and Subject from, with Data,
+	// see Templates.
+	Template string
+	Data     interface{}
+
+	// Labels are appended below the text as "key: value" lines, see Enrich.
+	Labels map[string]string
+
+	// Attachments are uploaded with the message on Slack, Discord, Telegram,
+	// Pushover (one image), Lark (images) and email, other platforms fail.
+	Attachments []*Attachment
+
+	// Actions are buttons below the message on Slack, Discord, Telegram,
+	// Lark and DingTalk, other platforms leave them out.
+	Actions []Action
+
+	// ThreadKey groups the messages about one incident: the first one
+	// starts a thread and the following ones reply to it, in a Slack
+	// thread, a Telegram reply chain, a Discord forum post or an email
+	// conversation. See ThreadStore.
+	ThreadKey string
+
+	// SendAt schedules the message on Slack, the receipt then identifies
+	// the scheduled message rather than a posted one.
+	SendAt time.Time
+	// EphemeralUser shows the message on Slack only to that user, given by
+	// id, "@handle" or email address, in the configured channel.
+	EphemeralUser string
+}
+
+// Attach adds a file to the message.
+func (m *Message) Attach(name, contentType string, data []byte) {
+	m.Attachments = append(m.Attachments, attachment.New(name, contentType, data))
+}
+
+// SetLabel sets a label, allocating Labels if needed.
+func (m *Message) SetLabel(key, value string) {
+	if m.Labels == nil {
+		m.Labels = make(map[string]string)
+	}
+	m.Labels[key] = value
+}
+
+func (m *Message) footer() string {
+	if len(m.Labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		lines = append(lines, k+": "+m.Labels[k])
+	}
+	return strings.Join(lines, "\n")
+}
+
+type emailInfo struct {
+	Subject string `json:"subject"`
+	Content string `json:"content"`
+}
+
+// render returns the text handed to the platform client.
+func (m *Message) render(platform Platform) string {
+	if m.Format == FormatMarkdown {
+		return m.renderMarkdown(platform)
+	}
+	footer := m.footer()
+	if m.Subject == "" && footer == "" {
+		return m.Text
+	}
+	switch platform {
+	case PlatformEmail, PlatformSes:
+		// the email clients take a json encoded subject and html content,
+		// or plain text used as both
+		info := emailInfo{Subject: m.Subject, Content: m.Text}
+		if m.Subject == "" {
+			if err := json.Unmarshal([]byte(m.Text), &info); err != nil {
+				info = emailInfo{Subject: m.Text, Content: m.Text}
+			}
+		}
+		if footer != "" {
+			info.Content += "<br/>" + strings.ReplaceAll(html.EscapeString(footer), "\n", "<br/>")
+		}
+		b, _ := json.Marshal(info)
+		return string(b)
+	}
+	text := m.Text
+	if m.Subject != "" {
+		text = m.Subject + "\n" + text
+	}
+	if footer != "" {
+		text += "\n\n" + footer
+	}
+	return text
+}
+
+// markdownDoc parses the text, with the subject as a bold first line when
+// withSubject is set and the labels below it.
+func (m *Message) markdownDoc(withSubject bool) *markdown.Node {
+	src := m.Text
+	if withSubject && m.Subject != "" {
+		src = "**" + markdown.Escape(m.Subject) + "**\n" + src
+	}
+	if footer := m.footer(); footer != "" {
+		src += "\n\n" + markdown.Escape(footer)
+	}
+	return markdown.Parse(src)
+}
+
+func (m *Message) renderMarkdown(platform Platform) string {
+	switch platform {
+	case PlatformSlack:
+		return markdown.RenderSlack(m.markdownDoc(true))
+	case PlatformDiscord:
+		return markdown.RenderDiscord(m.markdownDoc(true))
+	case PlatformTelegram:
+		return markdown.RenderTelegram(m.markdownDoc(true))
+	case PlatformDingTalk:
+		return markdown.RenderDingTalk(m.markdownDoc(true))
+	case PlatformEmail, PlatformSes:
+		doc := m.markdownDoc(false)
+		info := emailInfo{Subject: m.Subject, Content: markdown.RenderHTML(doc)}
+		if info.Subject == "" {
+			info.Subject = m.title()
+		}
+		b, _ := json.Marshal(info)
+		return string(b)
+	default:
+		return markdown.RenderText(m.markdownDoc(true))
+	}
+}
+
+// title returns the subject, or else the first line of the text, for
+// platforms that need a title.
+func (m *Message) title() string {
+	if m.Subject != "" {
+		return m.Subject
+	}
+	text := m.Text
+	if m.Format == FormatMarkdown {
+		text = markdown.RenderText(markdown.Parse(text))
+	}
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = text[:i]
+	}
+	return truncate(64, text)
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// Sender delivers a message.
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SenderFunc adapts a function to a Sender.
+type SenderFunc func(ctx context.Context, msg *Message) error
+
+func (f SenderFunc) Send(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Middleware wraps a Sender with extra behaviour, it is installed with
+// WithMiddleware.
+type Middleware func(next Sender) Sender
+
+// chain wraps final with mws, the first middleware being the outermost.
+func chain(mws []Middleware, final Sender) Sender {
+	s := final
+	for i := len(mws) - 1; i >= 0; i-- {
+		s = mws[i](s)
+	}
+	return s
+}
+
+type platformKey struct{}
+
+// clientKey holds whether the last attempt to send a message reached the
+// platform's client, which logs the outcome itself.
+type clientKey struct{}
+
+func setReachedClient(ctx context.Context, reached bool) {
+	if p, ok := ctx.Value(clientKey{}).(*int32); ok {
+		var v int32
+		if reached {
+			v = 1
+		}
+		atomic.StoreInt32(p, v)
+	}
+}
+
+func reachedClient(ctx context.Context) bool {
+	p, ok := ctx.Value(clientKey{}).(*int32)
+	return ok && atomic.LoadInt32(p) == 1
+}
+
+// PlatformFromContext returns the platform a message is being sent to, for
+// use by middlewares.
+func PlatformFromContext(ctx context.Context) Platform {
+	p, _ := ctx.Value(platformKey{}).(Platform)
+	return p
+}
+
+// Logging logs the outcome and duration of every delivery to l, the logrus
+// standard logger when nil. Use logger.Logrus or logger.Slog to adapt a
+// logrus or slog logger. Failures of the platform's client are logged by the
+// client to Config.Logger, only those before it, such as an unknown
+// template, are logged here.
+func Logging(l logger.Logger) Middleware {
+	log := logger.Default(l)
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			start := time.Now()
+			err := next.Send(ctx, msg)
+			fields := []logger.Field{
+				logger.Platform(string(PlatformFromContext(ctx))),
+				logger.Duration(time.Since(start)),
+			}
+			if err != nil {
+				if !reachedClient(ctx) {
+					log.Error("notify send failed", append(fields, logger.Err(err))...)
+				}
+			} else {
+				log.Debug("notify sent", fields...)
+			}
+			return err
+		})
+	}
+}
+
+// Recover turns a panic further down the chain into an error.
+func Recover() Middleware {
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("notify panic: %v\n%s", r, debug.Stack())
+				}
+			}()
+			return next.Send(ctx, msg)
+		})
+	}
+}
+
+// Enrich labels every message with the hostname of the machine and the given
+// environment and service name. Empty values are left out.
+func Enrich(environment, service string) Middleware {
+	hostname, _ := os.Hostname()
+	labels := map[string]string{
+		"host":        hostname,
+		"environment": environment,
+		"service":     service,
+	}
+	return func(next Sender) Sender {
+		return SenderFunc(func(ctx context.Context, msg *Message) error {
+			// label a copy, the caller's message may be sent elsewhere too
+			enriched := *msg
+			enriched.Labels = make(map[string]string, len(msg.Labels)+len(labels))
+			for k, v := range msg.Labels {
+				enriched.Labels[k] = v
+			}
+			for k, v := range labels {
+				if v != "" {
+					enriched.Labels[k] = v
+				}
+			}
+			return next.Send(ctx, &enriched)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/slack"
+)
+
+func TestMiddlewareChain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Sender) Sender {
+			return SenderFunc(func(ctx context.Context, msg *Message) error {
+				calls = append(calls, name)
+				return next.Send(ctx, msg)
+			})
+		}
+	}
+
+	var got string
+	final := SenderFunc(func(ctx context.Context, msg *Message) error {
+		got = msg.render(PlatformFromContext(ctx))
+		return nil
+	})
+
+	s := chain([]Middleware{record("first"), record("second"), Enrich("prod", "api")}, final)
+	ctx := context.WithValue(context.Background(), platformKey{}, Platform(PlatformSlack))
+	msg := &Message{Text: "disk full", Labels: map[string]string{"team": "infra"}}
+	if err := s.Send(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("middlewares ran in order %v", calls)
+	}
+	if !strings.HasPrefix(got, "disk full\n\n") || !strings.Contains(got, "environment: prod\n") || !strings.Contains(got, "service: api") {
+		t.Errorf("message not enriched: %q", got)
+	}
+	if len(msg.Labels) != 1 {
+		t.Errorf("labels of the sent message changed: %v", msg.Labels)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	s := chain([]Middleware{Recover()}, SenderFunc(func(ctx context.Context, msg *Message) error {
+		panic("boom")
+	}))
+	err := s.Send(context.Background(), &Message{Text: "test"})
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected panic to be returned as error, got %v", err)
+	}
+}
+
+func TestLogging_OnceAFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	defer func(prefix string) { slack.WebhookPrefix = prefix }(slack.WebhookPrefix)
+	slack.WebhookPrefix = srv.URL
+
+	var buf bytes.Buffer
+	l := logger.Slog(slog.New(slog.NewTextHandler(&buf, nil)))
+	n := NewNotify(&Config{Platform: PlatformSlack, Token: srv.URL + "/services/T0/B0/secret", Logger: l},
+		WithMiddleware(Logging(l)))
+
+	// the slack client logs its failure, the middleware those before it
+	for _, msg := range []*Message{{Text: "disk full"}, {Template: "disk_full"}} {
+		buf.Reset()
+		if err := n.SendMessage(context.Background(), msg); err == nil {
+			t.Fatal("no error")
+		}
+		if got := strings.Count(buf.String(), "notify send failed"); got != 1 {
+			t.Errorf("%+v: failure logged %d times:\n%s", msg, got, buf.String())
+		}
+	}
+}
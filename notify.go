@@ -1,17 +1,32 @@
 package notify
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ChainbotAI/go-notify/attachment"
 	"github.com/ChainbotAI/go-notify/dingtalk"
 	"github.com/ChainbotAI/go-notify/discord"
 	"github.com/ChainbotAI/go-notify/email"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
 	"github.com/ChainbotAI/go-notify/lark"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/markdown"
 	"github.com/ChainbotAI/go-notify/pagerduty"
 	"github.com/ChainbotAI/go-notify/pushover"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/ChainbotAI/go-notify/ses"
 	"github.com/ChainbotAI/go-notify/slack"
+	"github.com/ChainbotAI/go-notify/telegram"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Platform string
@@ -29,17 +44,39 @@ const (
 	PlatformArgus              = "Argus"
 )
 
+// NotifyChannelType is what Config.Channel names, on the platforms where it
+// can be more than one kind of target.
 type NotifyChannelType string
 
 const (
+	// NotifyChannelTypeBot posts as a bot: on Telegram to every chat in
+	// ChatIDs, on Discord to the channel id in Channel rather than with a
+	// webhook.
+	NotifyChannelTypeBot NotifyChannelType = "Bot"
+	// NotifyChannelTypeUser sends the bot's direct messages to the user id
+	// in Channel, on Discord. Telegram treats it like a chat.
+	NotifyChannelTypeUser NotifyChannelType = "User"
+
+	// NotifyChannelTypeTgGroup and NotifyChannelTypeTgChannel name the kind
+	// of Telegram chat in Channel, which is sent to the same way.
 	NotifyChannelTypeTgGroup   NotifyChannelType = "Group"
 	NotifyChannelTypeTgChannel NotifyChannelType = "Channel"
-	NotifyChannelTypeTgUser    NotifyChannelType = "User"
-	NotifyChannelTypeTgBot     NotifyChannelType = "Bot"
+
+	// Deprecated: use NotifyChannelTypeUser, which has the same value.
+	NotifyChannelTypeTgUser = NotifyChannelTypeUser
+	// Deprecated: use NotifyChannelTypeBot, which has the same value.
+	NotifyChannelTypeTgBot = NotifyChannelTypeBot
 )
 
 type Notify struct {
-	config *Config
+	config      *Config
+	middlewares []Middleware
+	sender      Sender
+	secrets     *secretStore
+	templates   *Templates
+	severities  map[Severity]SeverityStyle
+	threads     ThreadStore
+	audit       AuditSink
 }
 
 type Config struct {
@@ -62,120 +99,678 @@ type Config struct {
 	Priority    int
 	Others      map[string]string
 	ChatIDs     []int64
+
+	// LongMessage decides whether messages over the platform's length
+	// limit are split or truncated, MaxLength overrides that limit.
+	LongMessage LongMessageMode
+	MaxLength   int
+
+	// CallbackURL receives the clicks on DingTalk action buttons, which are
+	// signed with CallbackSecret, see the callback package.
+	CallbackURL    string
+	CallbackSecret string
+
+	// Logger receives the delivery logs of every provider, the logrus
+	// standard logger is used when it is nil.
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the credentials in Config, and
+// the DingTalk webhook url given as Channel, so it can be printed and logged
+// safely.
+func (c Config) String() string {
+	return c.printable().String()
+}
+
+func (c Config) GoString() string {
+	return c.printable().GoString()
+}
+
+func (c Config) MarshalJSON() ([]byte, error) {
+	return c.printable().MarshalJSON()
+}
+
+// config has the fields of Config without its methods.
+type config Config
+
+func (c Config) printable() redact.Printable {
+	c.Token = redact.Secret(c.Token)
+	c.Secret = redact.Secret(c.Secret)
+	c.Password = redact.Secret(c.Password)
+	c.Key = redact.Secret(c.Key)
+	c.CallbackSecret = redact.Secret(c.CallbackSecret)
+	if c.Platform == PlatformDingTalk {
+		c.Channel = redact.Secret(c.Channel)
+	}
+	return redact.Printable{V: config(c)}
+}
+
+// secrets lists the credentials in c, including webhook urls.
+func (c *Config) secrets() []string {
+	secrets := []string{c.Token, c.Secret, c.Password, c.Key, c.CallbackSecret}
+	if c.Platform == PlatformDingTalk {
+		secrets = append(secrets, c.Channel)
+	}
+	return secrets
+}
+
+// Option configures a Notify.
+type Option func(*Notify)
+
+// WithMiddleware wraps every delivery with mws, the first one being the
+// outermost.
+func WithMiddleware(mws ...Middleware) Option {
+	return func(n *Notify) {
+		n.middlewares = append(n.middlewares, mws...)
+	}
 }
 
-func NewNotify(config *Config) *Notify {
-	return &Notify{
-		config: config,
+func NewNotify(config *Config, opts ...Option) *Notify {
+	n := &Notify{
+		config:  config,
+		secrets: newSecretStore(),
+		threads: NewMemoryThreadStore(DefaultThreadTTL),
 	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.sender = chain(n.middlewares, SenderFunc(n.deliver))
+	return n
 }
 
 func (n *Notify) Send(msg string) error {
-	switch n.config.Platform {
+	return n.SendContext(context.Background(), msg)
+}
+
+// SendContext sends msg like Send. The delivery is recorded as an
+// OpenTelemetry span that is a child of the span in ctx, if any.
+func (n *Notify) SendContext(ctx context.Context, msg string) error {
+	return n.SendMessage(ctx, &Message{Text: msg})
+}
+
+// SendMessage passes msg through the middlewares and delivers it.
+func (n *Notify) SendMessage(ctx context.Context, msg *Message) error {
+	sender := n.sender
+	if sender == nil {
+		sender = SenderFunc(n.deliver)
+	}
+	if msg.ID == "" {
+		// the caller's message may be in flight on other Notify
+		withID := *msg
+		withID.ID = newMessageID()
+		msg = &withID
+	}
+	ctx = context.WithValue(ctx, platformKey{}, n.config.Platform)
+	ctx = context.WithValue(ctx, attemptKey{}, new(int32))
+	ctx = context.WithValue(ctx, clientKey{}, new(int32))
+	return sender.Send(ctx, msg)
+}
+
+// SendWithReceipt sends msg like SendMessage and returns the ids the
+// platform gave the messages, with which Update and Resolve edit them. The
+// receipt is empty when a middleware drops the message.
+func (n *Notify) SendWithReceipt(ctx context.Context, msg *Message) (*Receipt, error) {
+	r := &Receipt{}
+	if err := n.SendMessage(context.WithValue(ctx, receiptKey{}, r), msg); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// receiptKey holds the *Receipt deliver fills in for SendWithReceipt.
+type receiptKey struct{}
+
+// applyTemplate returns a copy of m with its template rendered into the
+// text and subject. m itself is left alone, as the same message may be sent
+// through several Notify at once.
+func (n *Notify) applyTemplate(m *Message) (*Message, error) {
+	if m.Template == "" {
+		return m, nil
+	}
+	if n.templates == nil {
+		return nil, errors.New("message template set but no templates configured")
+	}
+	subject, text, err := n.templates.render(m.Template, n.config.Platform, telegramParseMode(n.config, m), m.Data)
+	if err != nil {
+		return nil, err
+	}
+	rendered := *m
+	rendered.Text = text
+	if subject != "" {
+		rendered.Subject = subject
+	}
+	return &rendered, nil
+}
+
+func (n *Notify) deliver(ctx context.Context, m *Message) error {
+	start := time.Now()
+	ctx = logger.WithAttempt(ctx, nextAttempt(ctx))
+	setReachedClient(ctx, false)
+	rendered, err := n.applyTemplate(m)
+	if err != nil {
+		n.record(ctx, n.config, m, "", start, nil, err)
+		return err
+	}
+	m = rendered
+
+	msg := m.render(n.config.Platform)
+	ctx, span := tracing.Start(ctx, "notify.Send", trace.WithAttributes(
+		attribute.String("notify.platform", string(n.config.Platform)),
+		attribute.Int("notify.message_size", len(msg)),
+	))
+	cfg, err := n.resolveConfig(ctx)
+	var r *Receipt
+	sent := msg
+	if err == nil {
+		span.SetAttributes(attribute.String("notify.target_hash", cfg.targetHash()))
+		var text string
+		r, text, err = n.send(ctx, cfg, m, msg)
+		if text != "" {
+			sent = text
+		}
+		err = redact.New(cfg.secrets()...).Error(err)
+		if out, ok := ctx.Value(receiptKey{}).(*Receipt); ok && r != nil {
+			*out = *r
+		}
+	} else {
+		cfg = n.config
+	}
+	n.record(ctx, cfg, m, sent, start, r, err)
+	tracing.End(span, err)
+	return err
+}
+
+func (n *Notify) resolveConfig(ctx context.Context) (*Config, error) {
+	store := n.secrets
+	if store == nil {
+		store = newSecretStore()
+	}
+	return store.resolveConfig(ctx, n.config)
+}
+
+// acceptsAttachments are the platforms that can send files, acceptsActions
+// those that show buttons and acceptsTextFiles those that take any file
+// rather than only images.
+var (
+	acceptsAttachments = map[Platform]bool{
+		PlatformSlack:    true,
+		PlatformDiscord:  true,
+		PlatformTelegram: true,
+		PlatformPushover: true,
+		PlatformEmail:    true,
+		PlatformSes:      true,
+		PlatformLark:     true,
+	}
+	acceptsActions = map[Platform]bool{
+		PlatformSlack:    true,
+		PlatformDiscord:  true,
+		PlatformTelegram: true,
+		PlatformLark:     true,
+		PlatformDingTalk: true,
+	}
+	acceptsTextFiles = map[Platform]bool{
+		PlatformSlack:    true,
+		PlatformDiscord:  true,
+		PlatformTelegram: true,
+		PlatformEmail:    true,
+		PlatformSes:      true,
+	}
+)
+
+// delivery is what one call to a provider sends.
+type delivery struct {
+	text    string
+	files   []*Attachment
+	actions []Action
+	// style is nil for messages without a severity
+	style *SeverityStyle
+	// thread is the ThreadID of the receipt this delivery follows up on
+	thread string
+	// whole is set when text is the entire message rather than a part of it
+	whole bool
+}
+
+// send delivers msg, the rendering of m, and returns the receipt of the
+// first message with the ids of the others appended, and the text of the
+// messages as they were styled and split, which is empty when send failed
+// before.
+func (n *Notify) send(ctx context.Context, cfg *Config, m *Message, msg string) (r *Receipt, text string, err error) {
+	files := m.Attachments
+	if len(files) > 0 && !acceptsAttachments[cfg.Platform] {
+		return nil, text, fmt.Errorf("%s does not support attachments", cfg.Platform)
+	}
+	if !m.SendAt.IsZero() || m.EphemeralUser != "" {
+		if cfg.Platform != PlatformSlack {
+			return nil, text, fmt.Errorf("%s does not support scheduled or ephemeral messages", cfg.Platform)
+		}
+		if len(files) > 0 {
+			return nil, text, errors.New("scheduled and ephemeral messages can not carry attachments")
+		}
+	}
+	style, err := n.severityStyle(m)
+	if err != nil {
+		return nil, text, err
+	}
+	msg = cfg.styleText(style, msg)
+
+	parts := cfg.fitLength(m, msg)
+	text = strings.Join(parts, "\n")
+	if cfg.LongMessage == LongMessageAttach && parts[0] != msg && acceptsTextFiles[cfg.Platform] {
+		whole := msg
+		if m.Format == FormatMarkdown {
+			whole = markdown.RenderText(m.markdownDoc(true))
+		}
+		full := attachment.New("message.txt", "text/plain; charset=utf-8", []byte(whole))
+		files = append(files[:len(files):len(files)], full)
+	}
+
+	thread, err := n.loadThread(ctx, cfg, m)
+	if err != nil {
+		return nil, text, err
+	}
+	sent := &Receipt{
+		Platform: string(cfg.Platform),
+		Text:     m.Text,
+		Format:   string(m.Format),
+	}
+	// deliver sends d and threads what follows on the first receipt
+	deliver := func(d *delivery) error {
+		d.thread = thread
+		setReachedClient(ctx, true)
+		r, err := n.sendPart(ctx, cfg, m, d)
+		if err != nil || r == nil {
+			return err
+		}
+		if sent.SentAt.IsZero() {
+			sent.Channel = r.Channel
+			sent.ThreadID = r.ThreadID
+			sent.SentAt = r.SentAt
+			sent.Raw = r.Raw
+		}
+		sent.MessageIDs = append(sent.MessageIDs, r.MessageIDs...)
+		if thread == "" && m.ThreadKey != "" {
+			thread = r.ThreadID
+			n.storeThread(ctx, cfg, m, r)
+		}
+		return nil
+	}
+
+	for i, part := range parts {
+		d := &delivery{text: part, style: style, whole: len(parts) == 1}
+		if i < len(parts)-1 {
+			if err := deliver(d); err != nil {
+				return nil, text, err
+			}
+			continue
+		}
+		// attachments and buttons go with the last part, uploads can not
+		// carry buttons so the files follow the message then
+		d.actions = m.Actions
+		if len(files) > 0 && len(d.actions) > 0 && acceptsActions[cfg.Platform] {
+			if err := deliver(d); err != nil {
+				return nil, text, err
+			}
+			d = &delivery{style: style}
+		}
+		d.files = files
+		if err := deliver(d); err != nil {
+			return nil, text, err
+		}
+	}
+	// platforms without receipts took the message now
+	if sent.SentAt.IsZero() {
+		sent.SentAt = time.Now()
+	}
+	return sent, text, nil
+}
+
+// sendPart sends d, the receipt is nil for platforms that do not return one.
+func (n *Notify) sendPart(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	switch cfg.Platform {
 	case PlatformPushover:
-		return n.sendPushOverNotify(msg)
+		return n.sendPushOverNotify(ctx, cfg, m, d)
 	case PlatformSlack:
-		return n.sendSlackNotify(msg)
+		return n.sendSlackNotify(ctx, cfg, m, d)
 	case PlatformPagerduty:
-		return n.sendPagerdutyNotify(msg)
+		return n.sendPagerdutyNotify(ctx, cfg, m, d)
 	case PlatformDiscord:
-		return n.sendDiscordNotify(msg)
+		return n.sendDiscordNotify(ctx, cfg, m, d)
 	case PlatformDingTalk:
-		return n.sendDingTalkNotify(msg)
+		return n.sendDingTalkNotify(ctx, cfg, m, d)
 	case PlatformEmail:
 		// change to ses
-		return n.sendSesNotify(msg)
+		return n.sendSesNotify(ctx, cfg, m, d)
 	case PlatformSes:
-		return n.sendSesNotify(msg)
+		return n.sendSesNotify(ctx, cfg, m, d)
 	case PlatformLark:
-		return n.sendLarkNotify(msg)
+		return n.sendLarkNotify(ctx, cfg, m, d)
+	case PlatformTelegram:
+		return n.sendTelegramNotify(ctx, cfg, m, d)
+	default:
+		return nil, errors.New("not supported notify platform")
+	}
+}
+
+// targetHash identifies the destination of a notification in traces without
+// exposing it, several platforms address their target with a secret url.
+func (c *Config) targetHash() string {
+	var target string
+	switch c.Platform {
+	case PlatformSlack:
+		target = c.Channel
+		if slack.IsWebhookURL(c.Token) {
+			// the channel of a webhook is fixed by its url
+			target = c.Token
+		}
+	case PlatformPushover, PlatformDingTalk, PlatformTelegram:
+		target = c.Channel
+	case PlatformDiscord:
+		target = c.Channel + "/" + c.Token
 	default:
-		return errors.New("not supported notify platform")
+		target = c.Token
+	}
+	if target == "" {
+		return ""
 	}
-	return nil
+	sum := sha256.Sum256([]byte(target))
+	return hex.EncodeToString(sum[:8])
 }
 
-func (n *Notify) sendPushOverNotify(msg string) error {
+func (n *Notify) sendPushOverNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
 	options := pushover.Options{
-		Token:    n.config.Token,
-		User:     n.config.Channel,
-		Priority: n.config.Priority,
+		Token:    cfg.Token,
+		User:     cfg.Channel,
+		Priority: cfg.Priority,
+		Logger:   cfg.Logger,
 	}
-	if retry, exist := n.config.Others["retryInterval"]; exist {
+	if d.style != nil {
+		options.Priority = d.style.PushoverPriority
+	}
+	if retry, exist := cfg.Others["retryInterval"]; exist {
 		options.Retry, _ = strconv.ParseFloat(retry, 64)
 	}
-	if expire, exist := n.config.Others["retryExpire"]; exist {
+	if expire, exist := cfg.Others["retryExpire"]; exist {
 		options.Expire, _ = strconv.ParseFloat(expire, 64)
 	}
 	app := pushover.New(options)
-	err := app.Send(msg)
-	return err
+	if len(d.files) > 0 {
+		return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+	}
+	return app.SendWithReceipt(ctx, d.text)
 }
 
-func (n *Notify) sendSlackNotify(msg string) error {
-	app := slack.New(slack.Options{
-		Token:   n.config.Token,
-		Channel: n.config.Channel,
-	})
-	err := app.Send(msg)
-	return err
+func (n *Notify) sendSlackNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	options := slackOptions(cfg, d)
+	options.PostAt = m.SendAt
+	options.User = m.EphemeralUser
+	app := slack.New(options)
+	if len(d.files) > 0 {
+		return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+	}
+	return app.SendWithReceipt(ctx, d.text)
 }
 
-func (n *Notify) sendPagerdutyNotify(msg string) error {
-	app := pagerduty.New(pagerduty.Options{
-		Token:    n.config.Token,
-		Source:   n.config.Source,
-		Severity: n.config.Severity,
-	})
-	err := app.Send(msg)
-	return err
+func slackOptions(cfg *Config, d *delivery) slack.Options {
+	options := slack.Options{
+		Token:    cfg.Token,
+		Channel:  cfg.Channel,
+		Actions:  d.actions,
+		ThreadTS: d.thread,
+		Logger:   cfg.Logger,
+
+		Username:  cfg.Others["username"],
+		IconEmoji: cfg.Others["iconEmoji"],
+		IconURL:   cfg.Others["iconUrl"],
+	}
+	if d.style != nil {
+		options.Color = d.style.Color
+	}
+	return options
 }
 
-func (n *Notify) sendDiscordNotify(msg string) error {
-	app := discord.New(discord.Options{
-		Token:   n.config.Token,
-		Channel: n.config.Channel,
-	})
-	err := app.Send(msg)
-	return err
+func (n *Notify) sendPagerdutyNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	app := pagerduty.New(pagerdutyOptions(cfg, d))
+	return app.SendWithReceipt(ctx, d.text)
 }
 
-func (n *Notify) sendDingTalkNotify(msg string) error {
-	app := dingtalk.New(dingtalk.Options{
-		WebhookUrl: n.config.Channel,
-		Secret:     n.config.Token,
-	})
-	err := app.Send(msg)
-	return err
+func pagerdutyOptions(cfg *Config, d *delivery) pagerduty.Options {
+	options := pagerduty.Options{
+		Token:    cfg.Token,
+		Source:   cfg.Source,
+		Severity: cfg.Severity,
+		Logger:   cfg.Logger,
+	}
+	if d.style != nil {
+		options.Severity = d.style.PagerDuty
+	}
+	return options
 }
 
-func (n *Notify) sendEmailNotify(msg string) error {
-	app := email.New(email.Options{
-		ToEmail:  n.config.Token,
-		User:     n.config.User,
-		Password: n.config.Password,
-		Host:     n.config.Host,
-	})
-	err := app.Send(msg)
-	return err
+func (n *Notify) sendDiscordNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	options := discordOptions(cfg, d)
+	// messages to forum channels start posts named after them
+	if cfg.Others["forum"] == "true" && d.thread == "" {
+		options.ThreadName = m.title()
+	}
+	text := d.text
+	if d.whole && len(d.files) == 0 && (m.Subject != "" || len(m.Labels) > 0 || d.style != nil) {
+		// structured messages are laid out as an embed when they fit one
+		embeds := []*discord.Embed{discordEmbed(m, d)}
+		if discord.ValidateEmbeds(embeds) == nil {
+			options.Embeds = embeds
+			text = ""
+		}
+	}
+	app := discord.New(options)
+	if len(d.files) > 0 {
+		return app.SendAttachmentsWithReceipt(ctx, text, d.files)
+	}
+	return app.SendWithReceipt(ctx, text)
 }
 
-func (n *Notify) sendSesNotify(msg string) error {
-	app := ses.New(ses.Options{
-		ToEmail: n.config.Token,
-		Key:     n.config.Key,
-		Secret:  n.config.Secret,
-		Area:    n.config.Area,
-		Sender:  n.config.Sender,
-	})
-	err := app.Send(msg)
-	return err
+// discordEmbed lays out m as an embed: the subject is its title, the labels
+// its fields and the severity its color, which Options.Color sets.
+func discordEmbed(m *Message, d *delivery) *discord.Embed {
+	embed := &discord.Embed{Title: m.Subject, Description: m.Text}
+	if m.Format == FormatMarkdown {
+		embed.Description = markdown.RenderDiscord(markdown.Parse(m.Text))
+	}
+	if d.style != nil && d.style.Emoji != "" {
+		if embed.Title != "" {
+			embed.Title = d.style.Emoji + " " + embed.Title
+		} else {
+			embed.Description = d.style.Emoji + " " + embed.Description
+		}
+	}
+	keys := make([]string, 0, len(m.Labels))
+	for k := range m.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		embed.AddField(k, m.Labels[k], true)
+	}
+	now := time.Now()
+	embed.Timestamp = &now
+	return embed
 }
 
-func (n *Notify) sendLarkNotify(msg string) error {
+func discordOptions(cfg *Config, d *delivery) discord.Options {
+	options := discord.Options{
+		Token:    cfg.Token,
+		Channel:  cfg.Channel,
+		Actions:  d.actions,
+		ThreadID: d.thread,
+		Logger:   cfg.Logger,
+
+		Username:  cfg.Others["username"],
+		AvatarURL: cfg.Others["avatarUrl"],
+	}
+	// with a bot token, Channel is a channel id, or a user id to send
+	// direct messages to
+	switch cfg.ChannelType {
+	case NotifyChannelTypeBot:
+		options.Bot = true
+	case NotifyChannelTypeUser:
+		options.Bot = true
+		options.User, options.Channel = cfg.Channel, ""
+	}
+	// nobody is pinged unless allowedMentions names the mention types that
+	// ping, such as "users,roles", or is "all" for every mention in the text
+	options.AllowedMentions = discord.NoMentions()
+	switch mentions := cfg.Others["allowedMentions"]; mentions {
+	case "", "none":
+	case "all":
+		options.AllowedMentions = nil
+	default:
+		options.AllowedMentions.Parse = strings.Split(mentions, ",")
+	}
+	// tts reads the messages aloud, suppressEmbeds hides link previews
+	options.TTS = cfg.Others["tts"] == "true"
+	if cfg.Others["suppressEmbeds"] == "true" {
+		options.Flags |= discord.FlagSuppressEmbeds
+	}
+	if d.style != nil {
+		options.Color = d.style.Color
+		if d.style.Silent {
+			options.Flags |= discord.FlagSuppressNotifications
+		}
+	}
+	return options
+}
+
+func (n *Notify) sendDingTalkNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	options := dingtalk.Options{
+		WebhookUrl:     cfg.Channel,
+		Secret:         cfg.Token,
+		Actions:        d.actions,
+		CallbackURL:    cfg.CallbackURL,
+		CallbackSecret: cfg.CallbackSecret,
+		MessageID:      m.ID,
+		Logger:         cfg.Logger,
+	}
+	if d.style != nil {
+		options.AtAll = d.style.MentionAll
+	}
+	app := dingtalk.New(options)
+	if m.Format == FormatMarkdown {
+		return nil, app.SendMarkdown(ctx, m.title(), d.text)
+	}
+	return nil, app.SendContext(ctx, d.text)
+}
+
+func (n *Notify) sendEmailNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	options := email.Options{
+		ToEmail:   cfg.Token,
+		User:      cfg.User,
+		Password:  cfg.Password,
+		Host:      cfg.Host,
+		InReplyTo: d.thread,
+		Logger:    cfg.Logger,
+	}
+	if d.style != nil {
+		options.Priority = d.style.EmailPriority
+	}
+	app := email.New(options)
+	return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+}
+
+func (n *Notify) sendSesNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	options := ses.Options{
+		ToEmail:   cfg.Token,
+		Key:       cfg.Key,
+		Secret:    cfg.Secret,
+		Area:      cfg.Area,
+		Sender:    cfg.Sender,
+		InReplyTo: d.thread,
+		Logger:    cfg.Logger,
+	}
+	if d.style != nil {
+		options.Priority = d.style.EmailPriority
+	}
+	app := ses.New(options)
+	return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+}
+
+func (n *Notify) sendLarkNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
 	app := lark.New(lark.Options{
-		Token: n.config.Token,
+		Token:     cfg.Token,
+		AppID:     cfg.Key,
+		AppSecret: cfg.Secret,
+		Actions:   d.actions,
+		Logger:    cfg.Logger,
 	})
-	err := app.Send(msg)
-	return err
+	if d.text == "" {
+		// the files following a message with buttons
+		return app.SendAttachmentsWithReceipt(ctx, "", d.files)
+	}
+	if m.Format == FormatMarkdown && len(d.actions) > 0 {
+		return nil, app.SendCard(ctx, m.Subject, markdown.RenderLarkCard(m.markdownDoc(false)))
+	}
+	if m.Format == FormatMarkdown {
+		err := app.SendPost(ctx, m.Subject, markdown.RenderLarkPost(m.markdownDoc(false)))
+		if err != nil || len(d.files) == 0 {
+			return nil, err
+		}
+		return app.SendAttachmentsWithReceipt(ctx, "", d.files)
+	}
+	if len(d.files) > 0 {
+		return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+	}
+	return nil, app.SendContext(ctx, d.text)
+}
+
+func (n *Notify) sendTelegramNotify(ctx context.Context, cfg *Config, m *Message, d *delivery) (*Receipt, error) {
+	app := telegram.New(telegramOptions(cfg, m, d))
+	if app == nil {
+		return nil, errors.New("create telegram client failed")
+	}
+	if len(d.files) > 0 {
+		return app.SendAttachmentsWithReceipt(ctx, d.text, d.files)
+	}
+	return app.SendWithReceipt(ctx, d.text)
+}
+
+func telegramOptions(cfg *Config, m *Message, d *delivery) telegram.Options {
+	options := telegram.Options{
+		Token:       cfg.Token,
+		ChannelType: telegram.NotifyChannelType(cfg.ChannelType),
+		ChatIDs:     cfg.ChatIDs,
+		Actions:     d.actions,
+		ReplyTo:     d.thread,
+		Logger:      cfg.Logger,
+	}
+	// the channel is a chat id, or the @username of a public channel
+	if id, err := strconv.ParseInt(cfg.Channel, 10, 64); err == nil {
+		options.Channel = id
+	} else {
+		options.ChatName = cfg.Channel
+	}
+	if topic, exist := cfg.Others["topicId"]; exist {
+		options.TopicId, _ = strconv.Atoi(topic)
+	}
+	options.ParseMode = telegramParseMode(cfg, m)
+	if d.style != nil {
+		options.Silent = d.style.Silent
+	}
+	return options
+}
+
+// the Telegram parse modes
+const (
+	telegramMarkdown = "MarkdownV2"
+	telegramHTML     = "HTML"
+)
+
+// telegramParseMode returns the parse mode Telegram reads the text of m in:
+// markdown messages are rendered to MarkdownV2, the text of others is sent
+// as is in the parseMode of cfg, plain text when it is not set.
+func telegramParseMode(cfg *Config, m *Message) string {
+	if cfg.Platform != PlatformTelegram {
+		return ""
+	}
+	if m.Format == FormatMarkdown {
+		return telegramMarkdown
+	}
+	return cfg.Others["parseMode"]
 }
@@ -3,7 +3,10 @@ package notify
 import (
 	"encoding/json"
 	"os"
+	"reflect"
 	"testing"
+
+	"github.com/ChainbotAI/go-notify/discord"
 )
 
 func TestNotify_Send(t *testing.T) {
@@ -167,3 +170,25 @@ func TestNotify_Send(t *testing.T) {
 		})
 	}
 }
+
+func TestDiscordOptions(t *testing.T) {
+	tests := []struct {
+		others   map[string]string
+		style    *SeverityStyle
+		mentions *discord.AllowedMentions
+		tts      bool
+		flags    int
+	}{
+		{nil, nil, discord.NoMentions(), false, 0},
+		{map[string]string{"allowedMentions": "users,roles"}, nil, &discord.AllowedMentions{Parse: []string{"users", "roles"}}, false, 0},
+		{map[string]string{"allowedMentions": "all", "tts": "true"}, nil, nil, true, 0},
+		{map[string]string{"suppressEmbeds": "true"}, &SeverityStyle{Silent: true}, discord.NoMentions(),
+			false, discord.FlagSuppressEmbeds | discord.FlagSuppressNotifications},
+	}
+	for _, tt := range tests {
+		options := discordOptions(&Config{Platform: PlatformDiscord, Others: tt.others}, &delivery{style: tt.style})
+		if !reflect.DeepEqual(options.AllowedMentions, tt.mentions) || options.TTS != tt.tts || options.Flags != tt.flags {
+			t.Errorf("%v: got mentions %v, tts %v, flags %d", tt.others, options.AllowedMentions, options.TTS, options.Flags)
+		}
+	}
+}
@@ -2,12 +2,19 @@ package pagerduty
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 )
 
 const (
@@ -19,12 +26,38 @@ type Options struct {
 	Source   string `json:"source"`
 	Severity string `json:"severity"`
 	Text     string `json:"text"`
+	// DedupKey identifies the alert, triggers with the key of an open
+	// alert are grouped into it. PagerDuty generates one when it is empty.
+	DedupKey string `json:"dedup_key"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the routing key in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	return redact.Printable{V: options(o)}
 }
 
 type pagerduty struct {
-	Payload     payload `json:"payload"`
-	RoutingKey  string  `json:"routing_key"`
-	EventAction string  `json:"event_action"`
+	Payload     *payload `json:"payload,omitempty"`
+	RoutingKey  string   `json:"routing_key"`
+	EventAction string   `json:"event_action"`
+	DedupKey    string   `json:"dedup_key,omitempty"`
 }
 
 type payload struct {
@@ -40,7 +73,8 @@ type pagerdutyRes struct {
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 type Resp struct {
@@ -49,29 +83,93 @@ type Resp struct {
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Token)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt triggers an alert and returns its dedup_key, with which
+// Resolve resolves it.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "pagerduty", c.opt.Token, start, err)
+	return r, err
+}
+
+// Resolve resolves the alert triggered with the dedup_key of r.
+func (c *client) Resolve(ctx context.Context, r *receipt.Receipt) error {
+	start := time.Now()
+	err := c.redactor.Error(c.resolve(ctx, r))
+	logger.Delivery(ctx, c.opt.Logger, "pagerduty", c.opt.Token, start, err)
+	return err
+}
+
+func (c *client) send(ctx context.Context, message string) (*receipt.Receipt, error) {
 	err := c.check(message)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	pdOpt := &pagerduty{
-		Payload: payload{
+	res, body, err := c.enqueue(ctx, &pagerduty{
+		Payload: &payload{
 			Summary:  message,
 			Source:   c.opt.Source,
 			Severity: c.opt.Severity,
 		},
 		RoutingKey:  c.opt.Token,
 		EventAction: "trigger",
+		DedupKey:    c.opt.DedupKey,
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &receipt.Receipt{
+		Platform:   "pagerduty",
+		Channel:    c.opt.Source,
+		MessageIDs: []string{res.DedupKey},
+		SentAt:     time.Now(),
+		Raw:        receipt.JSON(body),
+	}, nil
+}
 
-	inrec, _ := json.Marshal(pdOpt)
-	resp, err := http.Post(ApiURL, "application/json", bytes.NewBuffer(inrec))
+func (c *client) resolve(ctx context.Context, r *receipt.Receipt) error {
+	if c.opt.Token == "" {
+		return errors.New("missing config")
+	}
+	if r.MessageID() == "" {
+		return errors.New("missing dedup key")
+	}
+	_, _, err := c.enqueue(ctx, &pagerduty{
+		RoutingKey:  c.opt.Token,
+		EventAction: "resolve",
+		DedupKey:    r.MessageID(),
+	})
+	return err
+}
+
+// enqueue sends an event to the Events API and returns the response with
+// its body.
+func (c *client) enqueue(ctx context.Context, event *pagerduty) (*pagerdutyRes, []byte, error) {
+	inrec, _ := json.Marshal(event)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, ApiURL, bytes.NewBuffer(inrec))
 	if err != nil {
-		return fmt.Errorf("pagerduty error: %s", err)
+		return nil, nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pagerduty error: %s", err)
 	}
 
 	defer func(Body io.ReadCloser) {
@@ -85,13 +183,13 @@ func (c *client) Send(message string) error {
 	res := &pagerdutyRes{}
 	err = json.Unmarshal(body, res)
 	if err != nil {
-		return fmt.Errorf("pagerduty server error: %s", string(body))
+		return nil, nil, fmt.Errorf("pagerduty server error: %s", string(body))
 	}
 
 	if res.Status != "success" {
-		return fmt.Errorf("send notify failed: %s", string(body))
+		return nil, nil, fmt.Errorf("send notify failed: %s", string(body))
 	}
-	return nil
+	return res, body, nil
 }
 
 func (c *client) check(msg string) error {
@@ -99,7 +197,7 @@ func (c *client) check(msg string) error {
 		return errors.New("missing config")
 	}
 
-	if  c.opt.Severity == "" {
+	if c.opt.Severity == "" {
 		c.opt.Severity = "critical"
 	}
 
@@ -0,0 +1,120 @@
+package pushover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// MaxAttachmentSize is the largest image Pushover accepts.
+const MaxAttachmentSize = 5 * 1024 * 1024
+
+// SendAttachments sends message with an image, Pushover takes one image per
+// message.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the ids like
+// SendWithReceipt.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.sendAttachments(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "pushover", c.opt.User, start, err)
+	return r, err
+}
+
+func (c *client) sendAttachments(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	if c.opt.Token == "" {
+		return nil, errors.New("missing token")
+	}
+	if c.opt.User == "" {
+		return nil, errors.New("missing user")
+	}
+	if message == "" {
+		return nil, errors.New("missing message")
+	}
+	if len(files) != 1 {
+		return nil, fmt.Errorf("pushover accepts a single attachment, got %d", len(files))
+	}
+	c.setDefaults()
+	f := files[0]
+	if !f.IsImage() {
+		return nil, fmt.Errorf("pushover only accepts image attachments, %s is %s", f.FileName(), f.Type())
+	}
+	data, err := f.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxAttachmentSize {
+		return nil, fmt.Errorf("attachment %s is larger than %d bytes", f.FileName(), MaxAttachmentSize)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fields := [][2]string{
+		{"token", c.opt.Token},
+		{"user", c.opt.User},
+		{"message", message},
+		{"priority", strconv.Itoa(c.opt.Priority)},
+	}
+	if c.opt.Retry != 0 {
+		fields = append(fields, [2]string{"retry", strconv.FormatFloat(c.opt.Retry, 'f', -1, 64)})
+	}
+	if c.opt.Expire != 0 {
+		fields = append(fields, [2]string{"expire", strconv.FormatFloat(c.opt.Expire, 'f', -1, 64)})
+	}
+	for _, field := range fields {
+		w.WriteField(field[0], field[1])
+	}
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": {`form-data; name="attachment"; filename=` + strconv.Quote(f.FileName())},
+		"Content-Type":        {f.Type()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	part.Write(data)
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, ApiURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rb, _ := ioutil.ReadAll(resp.Body)
+	r := &Resp{}
+	if err := json.Unmarshal(rb, r); err != nil {
+		return nil, fmt.Errorf("pushover server error: %s", string(rb))
+	}
+	if r.Status != 1 {
+		if len(r.Errors) > 0 {
+			return nil, errors.New(r.Errors[0])
+		}
+		return nil, fmt.Errorf("pushover error: %s", string(rb))
+	}
+	return r.receipt(rb), nil
+}
@@ -1,7 +1,14 @@
 package pushover
 
 import (
+	"context"
 	"errors"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/imroc/req"
 )
 
@@ -9,6 +16,15 @@ const (
 	ApiURL = "https://api.pushover.net/1/messages.json"
 )
 
+// PriorityEmergency messages repeat every Retry seconds until acknowledged
+// or Expire seconds have passed, DefaultRetry and DefaultExpire apply when
+// they are not set.
+const (
+	PriorityEmergency = 2
+	DefaultRetry      = 60
+	DefaultExpire     = 3600
+)
+
 // Options allows full configuration of the message sent to the Pushover API
 // https://pushover.net/api#messages
 type Options struct {
@@ -19,43 +35,122 @@ type Options struct {
 	Priority int     `json:"priority"`
 	Retry    float64 `json:"retry"`
 	Expire   float64 `json:"expire"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the app token and user key in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	o.User = redact.Secret(o.User)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Token, opt.User)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 type Resp struct {
-	Status int      `json:"status"`
-	Errors []string `json:"errors"`
+	Status  int      `json:"status"`
+	Errors  []string `json:"errors"`
+	Request string   `json:"request"`
+	// Receipt is returned for emergency messages, to poll whether they
+	// were acknowledged.
+	Receipt string `json:"receipt"`
+}
+
+// receipt returns the receipt of the message r answered, raw is its body.
+// The user key is a credential, so it is not kept as the channel.
+func (r *Resp) receipt(raw []byte) *receipt.Receipt {
+	ids := []string{r.Request}
+	if r.Receipt != "" {
+		ids = append(ids, r.Receipt)
+	}
+	return &receipt.Receipt{
+		Platform:   "pushover",
+		MessageIDs: ids,
+		SentAt:     time.Now(),
+		Raw:        receipt.JSON(raw),
+	}
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns its request id, followed by
+// the receipt of emergency messages.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "pushover", c.opt.User, start, err)
+	return r, err
+}
+
+func (c *client) setDefaults() {
+	if c.opt.Priority == PriorityEmergency {
+		if c.opt.Retry == 0 {
+			c.opt.Retry = DefaultRetry
+		}
+		if c.opt.Expire == 0 {
+			c.opt.Expire = DefaultExpire
+		}
+	}
+}
+
+func (c *client) send(ctx context.Context, message string) (*receipt.Receipt, error) {
 	if c.opt.Token == "" {
-		return errors.New("missing token")
+		return nil, errors.New("missing token")
 	}
 	if c.opt.User == "" {
-		return errors.New("missing user")
+		return nil, errors.New("missing user")
 	}
 	if message == "" {
-		return errors.New("missing message")
+		return nil, errors.New("missing message")
 	}
+	c.setDefaults()
 	c.opt.Message = message
-	resp, err := req.Post(ApiURL, req.BodyJSON(c.opt))
+	resp, err := req.Post(ApiURL, req.BodyJSON(options(c.opt)), ctx, tracing.HTTPClient)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	r := &Resp{}
 	err = resp.ToJSON(r)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	if r.Status != 1 {
-		return errors.New(r.Errors[0])
+		if len(r.Errors) > 0 {
+			return nil, errors.New(r.Errors[0])
+		}
+		return nil, errors.New("pushover error")
 	}
-	return nil
+	return r.receipt(resp.Bytes()), nil
 }
@@ -0,0 +1,51 @@
+// Package receipt describes what a provider delivered, as identified by the
+// platform.
+package receipt
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Receipt identifies the messages a provider sent. It can be stored as JSON
+// to update or resolve the messages later.
+type Receipt struct {
+	Platform string `json:"platform"`
+	// Channel is where the messages went, in the platform's terms.
+	Channel string `json:"channel,omitempty"`
+	// MessageIDs are the platform ids of the sent messages: the Slack ts,
+	// Telegram chat:message ids, Discord message ids, the email Message-ID,
+	// the PagerDuty dedup_key or the Pushover request followed by the
+	// receipt of emergency messages.
+	MessageIDs []string `json:"message_ids,omitempty"`
+	// ThreadID is what follow-up messages reply to: the Slack ts, the
+	// Telegram chat:message ids, the Discord thread or the email Message-ID.
+	ThreadID string `json:"thread_id,omitempty"`
+
+	// SentAt is when the platform took the message, by its own clock when
+	// the response tells.
+	SentAt time.Time `json:"sent_at"`
+	// Raw is the response of the platform, as JSON.
+	Raw json.RawMessage `json:"raw,omitempty"`
+
+	// Text and Format are the message as it was given, before rendering,
+	// for edits that keep it.
+	Text   string `json:"text,omitempty"`
+	Format string `json:"format,omitempty"`
+}
+
+// MessageID returns the first message id, or "" if there is none.
+func (r *Receipt) MessageID() string {
+	if r == nil || len(r.MessageIDs) == 0 {
+		return ""
+	}
+	return r.MessageIDs[0]
+}
+
+// JSON returns the response body b as Raw, nil when it is not JSON.
+func JSON(b []byte) json.RawMessage {
+	if len(b) == 0 || !json.Valid(b) {
+		return nil
+	}
+	return json.RawMessage(b)
+}
@@ -0,0 +1,35 @@
+package receipt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestReceiptJSON(t *testing.T) {
+	r := &Receipt{
+		Platform:   "Slack",
+		Channel:    "C1",
+		MessageIDs: []string{"1700000000.000100"},
+		SentAt:     time.Unix(1700000000, 100000).UTC(),
+		Raw:        JSON([]byte(`{"ok":true}`)),
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Receipt
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.MessageID() != r.MessageID() || !got.SentAt.Equal(r.SentAt) || string(got.Raw) != `{"ok":true}` {
+		t.Errorf("round trip gave %+v", got)
+	}
+
+	if raw := JSON([]byte("<html>bad gateway</html>")); raw != nil {
+		t.Errorf("JSON kept %s", raw)
+	}
+	if id := (*Receipt)(nil).MessageID(); id != "" {
+		t.Errorf("nil receipt has id %q", id)
+	}
+}
@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Printable prints configuration whose credentials were masked, for the
+// String, GoString and MarshalJSON methods of the configuration type:
+//
+//	func (o Options) String() string { return o.printable().String() }
+//
+//	func (o Options) printable() redact.Printable {
+//		o.Token = redact.Secret(o.Token)
+//		return redact.Printable{V: options(o)}
+//	}
+//
+// V must not have these methods, or printing it calls them again, so it is
+// of a type defined from the configuration type, such as options above.
+type Printable struct {
+	V interface{}
+}
+
+// String formats V like %+v.
+func (p Printable) String() string {
+	return fmt.Sprintf("%+v", p.V)
+}
+
+// GoString formats V like %#v.
+func (p Printable) GoString() string {
+	return fmt.Sprintf("%#v", p.V)
+}
+
+func (p Printable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.V)
+}
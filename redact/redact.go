@@ -0,0 +1,145 @@
+// Package redact scrubs credentials from errors, logs and printed
+// configuration.
+package redact
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// Mask replaces secrets in redacted output.
+const Mask = "[REDACTED]"
+
+// secrets shorter than this are not scrubbed, replacing them would mangle
+// unrelated text
+const minSecretLength = 4
+
+// Secret returns Mask for a non-empty secret, for use in String methods.
+func Secret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return Mask
+}
+
+// Redactor replaces registered secrets with Mask. The zero value is ready to
+// use and a nil Redactor leaves everything untouched.
+type Redactor struct {
+	mu      sync.RWMutex
+	secrets []string
+}
+
+// New returns a Redactor for secrets, empty ones are ignored.
+func New(secrets ...string) *Redactor {
+	r := &Redactor{}
+	r.Add(secrets...)
+	return r
+}
+
+// Add registers more secrets, including their url escaped forms since most
+// of them end up in request urls.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range secrets {
+		if len(s) < minSecretLength {
+			continue
+		}
+		r.secrets = append(r.secrets, s)
+		if escaped := url.QueryEscape(s); escaped != s {
+			r.secrets = append(r.secrets, escaped)
+		}
+		if escaped := url.PathEscape(s); escaped != s {
+			r.secrets = append(r.secrets, escaped)
+		}
+	}
+	// longest first so a webhook url is masked as a whole rather than
+	// around the token it contains
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+}
+
+// String returns s with every secret masked.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		s = strings.Replace(s, secret, Mask, -1)
+	}
+	return s
+}
+
+// Error returns err with every secret masked from its message. The original
+// error is still available through errors.Unwrap, errors.Is and errors.As.
+func (r *Redactor) Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := r.String(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{err: err, msg: msg}
+}
+
+type redactedError struct {
+	err error
+	msg string
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Logger returns a Logger that masks secrets from the message and the
+// string and error fields before passing them to l.
+func (r *Redactor) Logger(l logger.Logger) logger.Logger {
+	return &redactingLogger{r: r, l: logger.Default(l)}
+}
+
+type redactingLogger struct {
+	r *Redactor
+	l logger.Logger
+}
+
+func (l *redactingLogger) fields(fields []logger.Field) []logger.Field {
+	out := make([]logger.Field, len(fields))
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case string:
+			f.Value = l.r.String(v)
+		case error:
+			f.Value = l.r.Error(v)
+		}
+		out[i] = f
+	}
+	return out
+}
+
+func (l *redactingLogger) Debug(msg string, fields ...logger.Field) {
+	l.l.Debug(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Info(msg string, fields ...logger.Field) {
+	l.l.Info(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Warn(msg string, fields ...logger.Field) {
+	l.l.Warn(l.r.String(msg), l.fields(fields)...)
+}
+
+func (l *redactingLogger) Error(msg string, fields ...logger.Field) {
+	l.l.Error(l.r.String(msg), l.fields(fields)...)
+}
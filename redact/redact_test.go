@@ -0,0 +1,67 @@
+package redact
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := New("https://oapi.dingtalk.com/robot/send?access_token=abc123", "s3cr3t/t0ken", "", "ab")
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"post https://oapi.dingtalk.com/robot/send?access_token=abc123&sign=x: timeout", "post [REDACTED]&sign=x: timeout"},
+		{"webhooks/123/s3cr3t/t0ken failed", "webhooks/123/[REDACTED] failed"},
+		{"webhooks/123/s3cr3t%2Ft0ken failed", "webhooks/123/[REDACTED] failed"},
+		{"ab is too short to be masked", "ab is too short to be masked"},
+	}
+	for _, tt := range tests {
+		if got := r.String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	cause := errors.New("bot s3cr3t/t0ken unauthorized")
+	err := r.Error(cause)
+	if strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("secret not masked: %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Error("redacted error does not unwrap to its cause")
+	}
+	if r.Error(nil) != nil {
+		t.Error("nil error should stay nil")
+	}
+}
+
+type secretOptions struct {
+	Token string `json:"token"`
+	Name  string `json:"name"`
+}
+
+type printedOptions secretOptions
+
+func (o secretOptions) String() string {
+	return o.printable().String()
+}
+
+func (o secretOptions) printable() Printable {
+	o.Token = Secret(o.Token)
+	return Printable{V: printedOptions(o)}
+}
+
+func TestPrintable(t *testing.T) {
+	o := secretOptions{Token: "s3cr3t", Name: "ops"}
+	if got := o.String(); got != "{Token:[REDACTED] Name:ops}" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := o.printable().GoString(); got != `redact.printedOptions{Token:"[REDACTED]", Name:"ops"}` {
+		t.Errorf("GoString() = %q", got)
+	}
+	if b, _ := o.printable().MarshalJSON(); string(b) != `{"token":"[REDACTED]","name":"ops"}` {
+		t.Errorf("MarshalJSON() = %s", b)
+	}
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSecretTTL is how long resolved secrets are cached unless changed
+// with WithSecretTTL.
+const DefaultSecretTTL = 5 * time.Minute
+
+// SecretResolver looks up a secret from a reference.
+//
+// The Config fields holding credentials (Token, Secret, Password, Key and
+// CallbackSecret) may contain a reference of the form "scheme:reference"
+// instead of the value itself, e.g. "env:SLACK_TOKEN". References are
+// resolved at send time by the resolver registered for the scheme: "env" is
+// built in, others such as "file" or "vault" are added with
+// WithSecretResolver. Values whose scheme has no resolver, like webhook urls,
+// are used as they are.
+type SecretResolver interface {
+	// Resolve returns the secret for ref, the part after "scheme:".
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver.
+type SecretResolverFunc func(ctx context.Context, ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ctx context.Context, ref string) (string, error) {
+	return f(ctx, ref)
+}
+
+// EnvResolver resolves references to environment variables.
+func EnvResolver() SecretResolver {
+	return SecretResolverFunc(func(ctx context.Context, name string) (string, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	})
+}
+
+// FileResolver resolves references to the files in dir, such as docker or
+// kubernetes secrets in /run/secrets, by their path relative to dir or
+// absolute within it. Trailing newlines are removed. It is not registered by
+// default:
+//
+//	notify.WithSecretResolver("file", notify.FileResolver("/run/secrets"))
+func FileResolver(dir string) SecretResolver {
+	dir = filepath.Clean(dir)
+	return SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		path := ref
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		rel, err := filepath.Rel(dir, filepath.Clean(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("%s is outside of %s", ref, dir)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, rel))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	})
+}
+
+// WithSecretResolver registers r for references starting with "scheme:".
+func WithSecretResolver(scheme string, r SecretResolver) Option {
+	return func(n *Notify) {
+		n.secrets.resolvers[scheme] = r
+	}
+}
+
+// WithSecretTTL sets how long resolved secrets are cached, so rotated
+// credentials are picked up once it expires. Zero disables caching.
+func WithSecretTTL(ttl time.Duration) Option {
+	return func(n *Notify) {
+		n.secrets.ttl = ttl
+	}
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+type secretStore struct {
+	resolvers map[string]SecretResolver
+	ttl       time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+func newSecretStore() *secretStore {
+	return &secretStore{
+		resolvers: map[string]SecretResolver{
+			"env": EnvResolver(),
+		},
+		ttl:   DefaultSecretTTL,
+		cache: make(map[string]cachedSecret),
+	}
+}
+
+func (s *secretStore) resolve(ctx context.Context, value string) (string, error) {
+	i := strings.Index(value, ":")
+	if i <= 0 {
+		return value, nil
+	}
+	r, ok := s.resolvers[value[:i]]
+	if !ok {
+		return value, nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[value]
+	s.mu.Unlock()
+	if ok && s.ttl > 0 && now.Before(cached.expires) {
+		return cached.value, nil
+	}
+
+	secret, err := r.Resolve(ctx, value[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("resolve secret %s: %w", value, err)
+	}
+	if s.ttl > 0 {
+		s.mu.Lock()
+		s.cache[value] = cachedSecret{value: secret, expires: now.Add(s.ttl)}
+		s.mu.Unlock()
+	}
+	return secret, nil
+}
+
+// resolveConfig returns a copy of c with the secret references in its
+// credential fields resolved.
+func (s *secretStore) resolveConfig(ctx context.Context, c *Config) (*Config, error) {
+	resolved := *c
+	for _, field := range []*string{
+		&resolved.Token,
+		&resolved.Secret,
+		&resolved.Password,
+		&resolved.Key,
+		&resolved.CallbackSecret,
+	} {
+		v, err := s.resolve(ctx, *field)
+		if err != nil {
+			return nil, err
+		}
+		*field = v
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,98 @@
+package notify
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSecretStore_Resolve(t *testing.T) {
+	os.Setenv("NOTIFY_TEST_TOKEN", "xoxb-env")
+	defer os.Unsetenv("NOTIFY_TEST_TOKEN")
+	dir, err := ioutil.TempDir("", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pd")
+	if err := ioutil.WriteFile(path, []byte("pd-key\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSecretStore()
+	s.resolvers["file"] = FileResolver(dir)
+	tests := []struct {
+		value, want string
+		wantErr     bool
+	}{
+		{"plain-token", "plain-token", false},
+		{"env:NOTIFY_TEST_TOKEN", "xoxb-env", false},
+		{"file:" + path, "pd-key", false},
+		{"file:pd", "pd-key", false},
+		// files outside of the directory are refused
+		{"file:../" + filepath.Base(dir) + "/pd", "pd-key", false},
+		{"file:../pd", "", true},
+		{"file:/etc/hostname", "", true},
+		// values whose scheme has no resolver are not references
+		{"https://hooks.slack.com/services/T/B/X", "https://hooks.slack.com/services/T/B/X", false},
+		{"vault:secret/slack", "vault:secret/slack", false},
+		{"env:NOTIFY_TEST_MISSING", "", true},
+		{"file:" + filepath.Join(dir, "missing"), "", true},
+	}
+	if got, _ := newSecretStore().resolve(context.Background(), "file:"+path); got != "file:"+path {
+		t.Errorf("file resolved without being registered: %q", got)
+	}
+	for _, tt := range tests {
+		got, err := s.resolve(context.Background(), tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolve(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestSecretStore_TTL(t *testing.T) {
+	calls := 0
+	s := newSecretStore()
+	s.resolvers["vault"] = SecretResolverFunc(func(ctx context.Context, ref string) (string, error) {
+		calls++
+		return ref + "-v" + string(rune('0'+calls)), nil
+	})
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if got, _ := s.resolve(ctx, "vault:slack"); got != "slack-v1" {
+			t.Errorf("cached secret %q", got)
+		}
+	}
+	// rotated secrets are picked up once the cached one expires
+	s.cache["vault:slack"] = cachedSecret{value: "slack-v1", expires: time.Now().Add(-time.Second)}
+	if got, _ := s.resolve(ctx, "vault:slack"); got != "slack-v2" || calls != 2 {
+		t.Errorf("expired secret resolved to %q after %d calls", got, calls)
+	}
+
+	s.ttl = 0
+	s.resolve(ctx, "vault:slack")
+	if calls != 3 {
+		t.Errorf("secret cached without a ttl, %d calls", calls)
+	}
+}
+
+func TestSecretStore_ResolveConfig(t *testing.T) {
+	os.Setenv("NOTIFY_TEST_CALLBACK", "hmac-key")
+	defer os.Unsetenv("NOTIFY_TEST_CALLBACK")
+	s := newSecretStore()
+	cfg := &Config{Token: "plain", Channel: "env:NOTIFY_TEST_CALLBACK", CallbackSecret: "env:NOTIFY_TEST_CALLBACK"}
+	resolved, err := s.resolveConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the credential fields are resolved
+	if resolved.CallbackSecret != "hmac-key" || resolved.Token != "plain" || resolved.Channel != cfg.Channel || cfg.CallbackSecret != "env:NOTIFY_TEST_CALLBACK" {
+		t.Errorf("resolved %+v from %+v", resolved, cfg)
+	}
+	if _, err := s.resolveConfig(context.Background(), &Config{Password: "env:NOTIFY_TEST_MISSING"}); err == nil {
+		t.Error("missing secret resolved")
+	}
+}
@@ -1,15 +1,26 @@
 package ses
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"mime"
 	"strings"
+	"time"
 
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/email"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ses"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Options struct {
@@ -18,6 +29,34 @@ type Options struct {
 	Secret  string `json:"secret"`
 	Area    string `json:"host"`
 	Sender  string `json:"sender"`
+	// Priority sets the X-Priority header, from 1 (highest) to 5.
+	Priority int `json:"priority"`
+	// InReplyTo is the Message-ID of an earlier email, the message joins
+	// its conversation.
+	InReplyTo string `json:"in_reply_to"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the AWS access key and secret in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Key = redact.Secret(o.Key)
+	o.Secret = redact.Secret(o.Secret)
+	return redact.Printable{V: options(o)}
 }
 
 type Info struct {
@@ -26,20 +65,55 @@ type Info struct {
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Key, opt.Secret)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns the Message-ID SES gave it,
+// which later emails reply to with InReplyTo.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	return c.SendAttachmentsWithReceipt(ctx, message, nil)
+}
+
+// SendAttachments sends message as a raw email with files attached as MIME
+// parts.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the Message-ID
+// like SendWithReceipt.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "ses", c.opt.ToEmail, start, err)
+	return r, err
+}
+
+func (c *client) send(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
 	if "" == c.opt.ToEmail {
-		return errors.New("missing email address")
+		return nil, errors.New("missing email address")
 	}
 
 	if "" == message {
-		return errors.New("missing message")
+		return nil, errors.New("missing message")
 	}
 
 	var subject string
@@ -59,7 +133,7 @@ func (c *client) Send(message string) error {
 	secret := c.opt.Secret
 	area := c.opt.Area
 	if IsBlockEmail(c.opt.ToEmail) {
-		return errors.New(fmt.Sprintf("email %s is blocked", c.opt.ToEmail))
+		return nil, errors.New(fmt.Sprintf("email %s is blocked", c.opt.ToEmail))
 	}
 	to := []*string{
 		aws.String(c.opt.ToEmail),
@@ -67,21 +141,65 @@ func (c *client) Send(message string) error {
 	sender := c.opt.Sender
 	body := content
 
-	if err := SendToMail(key, secret, area, sender, subject, body, to); err != nil {
-		return errors.New("send email error: " + err.Error())
+	_, span := tracing.Start(ctx, "ses SendEmail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("cloud.region", area)),
+	)
+	var id string
+	if len(files) == 0 && c.opt.Priority == 0 && c.opt.InReplyTo == "" {
+		id, err = sendEmail(key, secret, area, sender, subject, body, to)
 	} else {
-		return nil
+		headers := append(rawHeaders(sender, subject, to), email.PriorityHeaders(c.opt.Priority)...)
+		if c.opt.InReplyTo != "" {
+			headers = append(headers, "In-Reply-To: "+c.opt.InReplyTo, "References: "+c.opt.InReplyTo)
+		}
+		id, err = sendRaw(key, secret, area, sender, headers, body, to, files)
+	}
+	tracing.End(span, err)
+	if err != nil {
+		return nil, errors.New("send email error: " + err.Error())
+	}
+	r := &receipt.Receipt{
+		Platform: "ses",
+		Channel:  c.opt.ToEmail,
+		ThreadID: c.opt.InReplyTo,
+		SentAt:   time.Now(),
+	}
+	if id != "" {
+		r.MessageIDs = []string{MessageID(area, id)}
+		// the MessageId is all SES answers
+		r.Raw, _ = json.Marshal(map[string]string{"MessageId": id})
 	}
+	if r.ThreadID == "" {
+		r.ThreadID = r.MessageID()
+	}
+	return r, nil
+}
+
+// MessageID returns the Message-ID header SES sets on the email it sent
+// as id from region.
+func MessageID(region, id string) string {
+	domain := region + ".amazonses.com"
+	if region == "us-east-1" {
+		domain = "email.amazonses.com"
+	}
+	return "<" + id + "@" + domain + ">"
 }
 
 func SendToMail(key string, secret string, area string, sender string, subject string, body string, to []*string) error {
+	_, err := sendEmail(key, secret, area, sender, subject, body, to)
+	return err
+}
+
+// sendEmail sends a simple email and returns the id SES gave it.
+func sendEmail(key string, secret string, area string, sender string, subject string, body string, to []*string) (string, error) {
 	sess, err := session.NewSession(&aws.Config{
 		Region:      aws.String(area),
 		Credentials: credentials.NewStaticCredentials(key, secret, ""),
 	})
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	svc := ses.New(sess)
@@ -106,12 +224,51 @@ func SendToMail(key string, secret string, area string, sender string, subject s
 		Source: aws.String(sender),
 	}
 
-	_, err_send_email := svc.SendEmail(input)
-	if err_send_email != nil {
-		return err
+	out, err := svc.SendEmail(input)
+	if err != nil || out == nil {
+		return "", err
 	}
+	return aws.StringValue(out.MessageId), nil
+}
 
-	return nil
+// SendRawMail is SendToMail for a multipart message with files attached.
+func SendRawMail(key string, secret string, area string, sender string, subject string, body string, to []*string, files []*attachment.Attachment) error {
+	_, err := sendRaw(key, secret, area, sender, rawHeaders(sender, subject, to), body, to, files)
+	return err
+}
+
+func rawHeaders(sender string, subject string, to []*string) []string {
+	return []string{
+		"From: " + sender,
+		"To: " + strings.Join(aws.StringValueSlice(to), ", "),
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+	}
+}
+
+func sendRaw(key string, secret string, area string, sender string, headers []string, body string, to []*string, files []*attachment.Attachment) (string, error) {
+	raw, err := attachment.MIME(headers, "text/html", body, files)
+	if err != nil {
+		return "", err
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:      aws.String(area),
+		Credentials: credentials.NewStaticCredentials(key, secret, ""),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	svc := ses.New(sess)
+	out, err := svc.SendRawEmail(&ses.SendRawEmailInput{
+		Destinations: to,
+		RawMessage:   &ses.RawMessage{Data: raw},
+		Source:       aws.String(sender),
+	})
+	if err != nil || out == nil {
+		return "", err
+	}
+	return aws.StringValue(out.MessageId), nil
 }
 
 func IsBlockEmail(email string) bool {
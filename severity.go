@@ -0,0 +1,128 @@
+package notify
+
+import "fmt"
+
+// Severity is the urgency of a message, mapped to each platform's own
+// priority by a SeverityStyle.
+type Severity string
+
+const (
+	SeverityDebug    Severity = "debug"
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityError    Severity = "error"
+	SeverityCritical Severity = "critical"
+)
+
+// ParseSeverity returns the Severity named s.
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(s); sev {
+	case SeverityDebug, SeverityInfo, SeverityWarning, SeverityError, SeverityCritical:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q", s)
+}
+
+// SeverityStyle is how a severity shows on each platform.
+type SeverityStyle struct {
+	// PagerDuty is the event severity: critical, error, warning or info.
+	PagerDuty string
+	// PushoverPriority goes from -2 (no notification) to 2 (emergency,
+	// repeated until acknowledged).
+	PushoverPriority int
+	// Silent sends Telegram messages without sound and Discord messages
+	// without a notification.
+	Silent bool
+	// Color is the bar beside Slack and Discord messages, as "#rrggbb".
+	Color string
+	// Emoji starts Slack and Discord messages.
+	Emoji string
+	// MentionAll mentions everyone in DingTalk groups.
+	MentionAll bool
+	// EmailPriority is the X-Priority header, from 1 (highest) to 5.
+	EmailPriority int
+}
+
+// DefaultSeverityStyles maps every severity, WithSeverityStyles replaces
+// entries.
+var DefaultSeverityStyles = map[Severity]SeverityStyle{
+	SeverityDebug: {
+		PagerDuty:        "info",
+		PushoverPriority: -2,
+		Silent:           true,
+		Color:            "#9e9e9e",
+		Emoji:            "🐛",
+		EmailPriority:    5,
+	},
+	SeverityInfo: {
+		PagerDuty:        "info",
+		PushoverPriority: -1,
+		Silent:           true,
+		Color:            "#2196f3",
+		Emoji:            "ℹ️",
+		EmailPriority:    3,
+	},
+	SeverityWarning: {
+		PagerDuty:        "warning",
+		PushoverPriority: 0,
+		Color:            "#ffc107",
+		Emoji:            "⚠️",
+		EmailPriority:    3,
+	},
+	SeverityError: {
+		PagerDuty:        "error",
+		PushoverPriority: 1,
+		Color:            "#f44336",
+		Emoji:            "❌",
+		EmailPriority:    2,
+	},
+	SeverityCritical: {
+		PagerDuty:        "critical",
+		PushoverPriority: 2,
+		Color:            "#b71c1c",
+		Emoji:            "🚨",
+		MentionAll:       true,
+		EmailPriority:    1,
+	},
+}
+
+// WithSeverityStyles overrides how the given severities show, the others
+// keep DefaultSeverityStyles. New severities can be added too.
+func WithSeverityStyles(styles map[Severity]SeverityStyle) Option {
+	return func(n *Notify) {
+		if n.severities == nil {
+			n.severities = make(map[Severity]SeverityStyle, len(DefaultSeverityStyles)+len(styles))
+			for sev, style := range DefaultSeverityStyles {
+				n.severities[sev] = style
+			}
+		}
+		for sev, style := range styles {
+			n.severities[sev] = style
+		}
+	}
+}
+
+// severityStyle returns the style of m's severity, or nil when m has none.
+func (n *Notify) severityStyle(m *Message) (*SeverityStyle, error) {
+	if m.Severity == "" {
+		return nil, nil
+	}
+	styles := n.severities
+	if styles == nil {
+		styles = DefaultSeverityStyles
+	}
+	style, ok := styles[m.Severity]
+	if !ok {
+		return nil, fmt.Errorf("unknown severity %q", m.Severity)
+	}
+	return &style, nil
+}
+
+// styleText starts msg with the emoji of style on the platforms showing
+// one.
+func (c *Config) styleText(style *SeverityStyle, msg string) string {
+	if style != nil && style.Emoji != "" && (c.Platform == PlatformSlack || c.Platform == PlatformDiscord) {
+		return style.Emoji + " " + msg
+	}
+	return msg
+}
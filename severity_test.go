@@ -0,0 +1,39 @@
+package notify
+
+import "testing"
+
+func TestSeverityStyles(t *testing.T) {
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+
+	n := NewNotify(&Config{Platform: PlatformPushover}, WithSeverityStyles(map[Severity]SeverityStyle{
+		SeverityWarning: {PushoverPriority: 1, PagerDuty: "warning"},
+		"page":          {PushoverPriority: 2, PagerDuty: "critical"},
+	}))
+
+	tests := []struct {
+		severity Severity
+		priority int
+	}{
+		{SeverityWarning, 1},
+		{"page", 2},
+		{SeverityCritical, DefaultSeverityStyles[SeverityCritical].PushoverPriority},
+	}
+	for _, tt := range tests {
+		style, err := n.severityStyle(&Message{Severity: tt.severity})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if style.PushoverPriority != tt.priority {
+			t.Errorf("%s: priority %d, want %d", tt.severity, style.PushoverPriority, tt.priority)
+		}
+	}
+
+	if style, _ := n.severityStyle(&Message{}); style != nil {
+		t.Error("messages without a severity should keep the config")
+	}
+	if _, err := NewNotify(&Config{}).severityStyle(&Message{Severity: "page"}); err == nil {
+		t.Error("custom severities should only exist where they are configured")
+	}
+}
@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+// actionBlocks lays out text as section blocks followed by the buttons.
+func actionBlocks(text string, actions []action.Action) *Blocks {
+	blocks := NewBlocks()
+	for text != "" {
+		chunk := text
+		if utf8.RuneCountInString(chunk) > MaxSectionText {
+			chunk = string([]rune(chunk)[:MaxSectionText])
+		}
+		text = text[len(chunk):]
+		blocks.Section(chunk)
+	}
+	return blocks.Actions(actions...)
+}
+
+// MaxRequestAge is how old a signed request from Slack may be.
+const MaxRequestAge = 5 * time.Minute
+
+// VerifyRequest checks the X-Slack-Signature of a request from Slack against
+// the app's signing secret.
+// https://api.slack.com/authentication/verifying-requests-from-slack
+func VerifyRequest(signingSecret string, header http.Header, body []byte) error {
+	if signingSecret == "" {
+		return errors.New("missing signing secret")
+	}
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("missing request timestamp")
+	}
+	if age := time.Since(time.Unix(sec, 0)); age > MaxRequestAge || age < -MaxRequestAge {
+		return errors.New("request timestamp too old")
+	}
+
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(header.Get("X-Slack-Signature"))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+package slack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+// Block Kit limits, messages breaking them are refused by Slack.
+// https://api.slack.com/reference/block-kit/blocks
+const (
+	MaxBlocks          = 50
+	MaxSectionText     = 3000
+	MaxSectionFields   = 10
+	MaxFieldText       = 2000
+	MaxHeaderText      = 150
+	MaxContextElements = 10
+	MaxActionElements  = 25
+	MaxButtonText      = 75
+)
+
+// Text is a text object, plain_text or mrkdwn.
+type Text struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+// Markdown returns a mrkdwn text object.
+func Markdown(text string) *Text {
+	return &Text{Type: "mrkdwn", Text: text}
+}
+
+// PlainText returns a plain_text text object.
+func PlainText(text string) *Text {
+	return &Text{Type: "plain_text", Text: text, Emoji: true}
+}
+
+func (t *Text) check(what string, max int) error {
+	if t == nil || t.Text == "" {
+		return fmt.Errorf("%s: missing text", what)
+	}
+	if n := utf8.RuneCountInString(t.Text); n > max {
+		return fmt.Errorf("%s: text of %d characters, the limit is %d", what, n, max)
+	}
+	return nil
+}
+
+// Block is a layout block of a message, one of the *Block types here.
+type Block interface {
+	validate() error
+}
+
+// SectionBlock shows text, fields in two columns, or both.
+type SectionBlock struct {
+	Text    *Text   `json:"text,omitempty"`
+	Fields  []*Text `json:"fields,omitempty"`
+	BlockID string  `json:"block_id,omitempty"`
+}
+
+// HeaderBlock is a large plain text title.
+type HeaderBlock struct {
+	Text    *Text  `json:"text"`
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// ContextBlock shows small text and images.
+type ContextBlock struct {
+	Elements []interface{} `json:"elements"`
+	BlockID  string        `json:"block_id,omitempty"`
+}
+
+// DividerBlock is a horizontal rule.
+type DividerBlock struct {
+	BlockID string `json:"block_id,omitempty"`
+}
+
+// ImageBlock shows the image at ImageURL.
+type ImageBlock struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+	Title    *Text  `json:"title,omitempty"`
+	BlockID  string `json:"block_id,omitempty"`
+}
+
+// ImageElement is an image in a ContextBlock.
+type ImageElement struct {
+	ImageURL string `json:"image_url"`
+	AltText  string `json:"alt_text"`
+}
+
+// ActionsBlock shows buttons.
+type ActionsBlock struct {
+	Elements []*ButtonElement `json:"elements"`
+	BlockID  string           `json:"block_id,omitempty"`
+}
+
+// ButtonElement is a button in an ActionsBlock.
+type ButtonElement struct {
+	Text     *Text  `json:"text"`
+	ActionID string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	URL      string `json:"url,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+// Button returns the button of a.
+func Button(a action.Action) *ButtonElement {
+	b := &ButtonElement{
+		Text:     PlainText(a.Label),
+		ActionID: a.ID,
+		Value:    a.Value,
+		URL:      a.URL,
+	}
+	if a.Style != action.StyleDefault {
+		b.Style = string(a.Style)
+	}
+	return b
+}
+
+// The MarshalJSON methods add the type of each block.
+
+func (b *SectionBlock) MarshalJSON() ([]byte, error) {
+	type block SectionBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"section", (*block)(b)})
+}
+
+func (b *HeaderBlock) MarshalJSON() ([]byte, error) {
+	type block HeaderBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"header", (*block)(b)})
+}
+
+func (b *ContextBlock) MarshalJSON() ([]byte, error) {
+	type block ContextBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"context", (*block)(b)})
+}
+
+func (b *DividerBlock) MarshalJSON() ([]byte, error) {
+	type block DividerBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"divider", (*block)(b)})
+}
+
+func (b *ImageBlock) MarshalJSON() ([]byte, error) {
+	type block ImageBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"image", (*block)(b)})
+}
+
+func (e *ImageElement) MarshalJSON() ([]byte, error) {
+	type element ImageElement
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*element
+	}{"image", (*element)(e)})
+}
+
+func (b *ActionsBlock) MarshalJSON() ([]byte, error) {
+	type block ActionsBlock
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*block
+	}{"actions", (*block)(b)})
+}
+
+func (e *ButtonElement) MarshalJSON() ([]byte, error) {
+	type element ButtonElement
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		*element
+	}{"button", (*element)(e)})
+}
+
+func (b *SectionBlock) validate() error {
+	if b.Text == nil && len(b.Fields) == 0 {
+		return errors.New("section: missing text or fields")
+	}
+	if b.Text != nil {
+		if err := b.Text.check("section", MaxSectionText); err != nil {
+			return err
+		}
+	}
+	if len(b.Fields) > MaxSectionFields {
+		return fmt.Errorf("section: %d fields, the limit is %d", len(b.Fields), MaxSectionFields)
+	}
+	for _, f := range b.Fields {
+		if err := f.check("section field", MaxFieldText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *HeaderBlock) validate() error {
+	if b.Text != nil && b.Text.Type != "plain_text" {
+		return errors.New("header: text must be plain_text")
+	}
+	return b.Text.check("header", MaxHeaderText)
+}
+
+func (b *ContextBlock) validate() error {
+	if len(b.Elements) == 0 || len(b.Elements) > MaxContextElements {
+		return fmt.Errorf("context: %d elements, it takes 1 to %d", len(b.Elements), MaxContextElements)
+	}
+	for _, e := range b.Elements {
+		switch e := e.(type) {
+		case *Text:
+			if err := e.check("context", MaxSectionText); err != nil {
+				return err
+			}
+		case *ImageElement:
+			if e.ImageURL == "" || e.AltText == "" {
+				return errors.New("context: image needs a url and alt text")
+			}
+		default:
+			return fmt.Errorf("context: unsupported element %T", e)
+		}
+	}
+	return nil
+}
+
+func (b *DividerBlock) validate() error {
+	return nil
+}
+
+func (b *ImageBlock) validate() error {
+	if b.ImageURL == "" || b.AltText == "" {
+		return errors.New("image: missing url or alt text")
+	}
+	return nil
+}
+
+func (b *ActionsBlock) validate() error {
+	if len(b.Elements) == 0 || len(b.Elements) > MaxActionElements {
+		return fmt.Errorf("actions: %d buttons, it takes 1 to %d", len(b.Elements), MaxActionElements)
+	}
+	for _, e := range b.Elements {
+		if err := e.Text.check("button", MaxButtonText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Blocks builds the blocks of a message:
+//
+//	slack.NewBlocks().
+//		Header("Disk full").
+//		Fields("*Host*\ndb-1", "*Usage*\n98%").
+//		Divider().
+//		Actions(ack)
+type Blocks struct {
+	blocks []Block
+}
+
+// NewBlocks returns an empty builder.
+func NewBlocks() *Blocks {
+	return &Blocks{}
+}
+
+// Add appends blocks.
+func (b *Blocks) Add(blocks ...Block) *Blocks {
+	b.blocks = append(b.blocks, blocks...)
+	return b
+}
+
+// Header appends a title.
+func (b *Blocks) Header(text string) *Blocks {
+	return b.Add(&HeaderBlock{Text: PlainText(text)})
+}
+
+// Section appends mrkdwn text.
+func (b *Blocks) Section(markdown string) *Blocks {
+	return b.Add(&SectionBlock{Text: Markdown(markdown)})
+}
+
+// Fields appends a section of mrkdwn fields, shown in two columns.
+func (b *Blocks) Fields(fields ...string) *Blocks {
+	section := &SectionBlock{}
+	for _, f := range fields {
+		section.Fields = append(section.Fields, Markdown(f))
+	}
+	return b.Add(section)
+}
+
+// Context appends small mrkdwn texts.
+func (b *Blocks) Context(texts ...string) *Blocks {
+	context := &ContextBlock{}
+	for _, t := range texts {
+		context.Elements = append(context.Elements, Markdown(t))
+	}
+	return b.Add(context)
+}
+
+// Divider appends a horizontal rule.
+func (b *Blocks) Divider() *Blocks {
+	return b.Add(&DividerBlock{})
+}
+
+// Image appends the image at url.
+func (b *Blocks) Image(url, altText string) *Blocks {
+	return b.Add(&ImageBlock{ImageURL: url, AltText: altText})
+}
+
+// Actions appends buttons.
+func (b *Blocks) Actions(actions ...action.Action) *Blocks {
+	block := &ActionsBlock{}
+	for _, a := range actions {
+		block.Elements = append(block.Elements, Button(a))
+	}
+	return b.Add(block)
+}
+
+// Blocks returns the blocks added so far.
+func (b *Blocks) Blocks() []Block {
+	return b.blocks
+}
+
+// Validate checks the blocks against the Block Kit limits.
+func (b *Blocks) Validate() error {
+	if len(b.blocks) > MaxBlocks {
+		return fmt.Errorf("%d blocks, the limit is %d", len(b.blocks), MaxBlocks)
+	}
+	for i, block := range b.blocks {
+		if block == nil {
+			return fmt.Errorf("block %d is nil", i)
+		}
+		if err := block.validate(); err != nil {
+			return fmt.Errorf("block %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// MarshalJSON returns the blocks as Slack takes them, after validating
+// them.
+func (b *Blocks) MarshalJSON() ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	if b.blocks == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(b.blocks)
+}
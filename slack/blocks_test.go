@@ -0,0 +1,57 @@
+package slack
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/action"
+)
+
+func TestBlocksJSON(t *testing.T) {
+	b := NewBlocks().
+		Header("Disk full").
+		Fields("*Host*\ndb-1", "*Usage*\n98%").
+		Context("since 10:02").
+		Divider().
+		Actions(action.Action{ID: "ack", Label: "Ack", Style: action.StylePrimary})
+	got, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"type":"header","text":{"type":"plain_text","text":"Disk full","emoji":true}},` +
+		`{"type":"section","fields":[{"type":"mrkdwn","text":"*Host*\ndb-1"},{"type":"mrkdwn","text":"*Usage*\n98%"}]},` +
+		`{"type":"context","elements":[{"type":"mrkdwn","text":"since 10:02"}]},` +
+		`{"type":"divider"},` +
+		`{"type":"actions","elements":[{"type":"button","text":{"type":"plain_text","text":"Ack","emoji":true},"action_id":"ack","style":"primary"}]}]`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+func TestBlocksLimits(t *testing.T) {
+	fields := make([]string, MaxSectionFields+1)
+	for i := range fields {
+		fields[i] = "f"
+	}
+	tooMany := NewBlocks()
+	for i := 0; i <= MaxBlocks; i++ {
+		tooMany.Divider()
+	}
+
+	tests := map[string]*Blocks{
+		"fields":  NewBlocks().Fields(fields...),
+		"section": NewBlocks().Section(strings.Repeat("a", MaxSectionText+1)),
+		"header":  NewBlocks().Header(strings.Repeat("a", MaxHeaderText+1)),
+		"blocks":  tooMany,
+		"empty":   NewBlocks().Section(""),
+	}
+	for name, b := range tests {
+		if err := b.Validate(); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+		if _, err := json.Marshal(b); err == nil {
+			t.Errorf("%s: marshalled invalid blocks", name)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// BlockAction is a click on a button of a message, from a block_actions
+// payload.
+type BlockAction struct {
+	ActionID string
+	BlockID  string
+	Value    string
+
+	UserID      string
+	UserName    string
+	ChannelID   string
+	MessageTS   string
+	ResponseURL string
+	TriggerID   string
+
+	// Payload is the whole block_actions payload.
+	Payload json.RawMessage
+}
+
+// SlashCommand is a slash command typed by a user, such as "/ack 123".
+type SlashCommand struct {
+	Command     string
+	Text        string
+	UserID      string
+	UserName    string
+	ChannelID   string
+	ChannelName string
+	TeamID      string
+	ResponseURL string
+	TriggerID   string
+}
+
+// Event is an Events API event, its common message fields decoded.
+type Event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	BotID       string `json:"bot_id"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts"`
+	EventTS     string `json:"event_ts"`
+
+	TeamID  string `json:"-"`
+	EventID string `json:"-"`
+	// Raw is the event object, for the fields of other event types.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ActionHandler handles a button click, reply is posted back to the user
+// who clicked as an ephemeral message.
+type ActionHandler func(ctx context.Context, a *BlockAction) (reply string, err error)
+
+// CommandHandler handles a slash command, reply is shown to the user who
+// typed it.
+type CommandHandler func(ctx context.Context, c *SlashCommand) (reply string, err error)
+
+// EventHandler handles an event. Slack retries events that fail.
+type EventHandler func(ctx context.Context, e *Event) error
+
+// ReceiverOptions configures a Receiver.
+type ReceiverOptions struct {
+	// SigningSecret is the app's signing secret, see VerifyRequest.
+	SigningSecret string
+
+	Logger logger.Logger
+}
+
+// Receiver is an http.Handler for the Events API, interactivity and slash
+// command requests of a Slack app; the three request urls of the app may
+// all point to it. Requests are verified with the signing secret, then
+// routed to the handlers registered for their action id, command or event
+// type. Slack wants an answer within three seconds, so slow work belongs
+// in a goroutine.
+//
+//	r := slack.NewReceiver(slack.ReceiverOptions{SigningSecret: secret})
+//	r.HandleCommand("/ack", func(ctx context.Context, c *slack.SlashCommand) (string, error) {
+//		return "Acknowledged " + c.Text, ack(c.Text)
+//	})
+//	http.Handle("/slack", r)
+type Receiver struct {
+	opt ReceiverOptions
+
+	mu       sync.RWMutex
+	actions  map[string]ActionHandler
+	commands map[string]CommandHandler
+	events   map[string]EventHandler
+}
+
+// NewReceiver returns a Receiver without handlers.
+func NewReceiver(opt ReceiverOptions) *Receiver {
+	opt.Logger = logger.Default(opt.Logger)
+	return &Receiver{
+		opt:      opt,
+		actions:  make(map[string]ActionHandler),
+		commands: make(map[string]CommandHandler),
+		events:   make(map[string]EventHandler),
+	}
+}
+
+// HandleAction registers fn for the buttons with actionID, or for the
+// buttons without a handler of their own when actionID is empty. Such a
+// handler returns ErrNoHandler for the actions it does not know either.
+func (rc *Receiver) HandleAction(actionID string, fn ActionHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.actions[actionID] = fn
+}
+
+// HandleCommand registers fn for command, with its slash.
+func (rc *Receiver) HandleCommand(command string, fn CommandHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.commands[command] = fn
+}
+
+// HandleEvent registers fn for the events of eventType, such as "message"
+// or "app_mention". Events without a handler are acknowledged and dropped.
+func (rc *Receiver) HandleEvent(eventType string, fn EventHandler) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.events[eventType] = fn
+}
+
+// maxEventBody is the largest request read.
+const maxEventBody = 1 << 20
+
+// ErrNoHandler answers the actions no handler is registered for.
+var ErrNoHandler = errors.New("no handler")
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxEventBody))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := VerifyRequest(rc.opt.SigningSecret, r.Header, body); err != nil {
+		rc.opt.Logger.Warn("slack request refused", logger.Platform("slack"), logger.Err(err))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		rc.serveEvent(w, r, body)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if payload := form.Get("payload"); payload != "" {
+		rc.serveInteraction(w, r, []byte(payload))
+		return
+	}
+	if form.Get("command") != "" {
+		rc.serveCommand(w, r, form)
+		return
+	}
+	http.Error(w, "unknown request", http.StatusBadRequest)
+}
+
+func (rc *Receiver) serveEvent(w http.ResponseWriter, r *http.Request, body []byte) {
+	var envelope struct {
+		Type      string          `json:"type"`
+		Challenge string          `json:"challenge"`
+		TeamID    string          `json:"team_id"`
+		EventID   string          `json:"event_id"`
+		Event     json.RawMessage `json:"event"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	switch envelope.Type {
+	case "url_verification":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(envelope.Challenge))
+		return
+	case "event_callback":
+	default:
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	e := &Event{TeamID: envelope.TeamID, EventID: envelope.EventID, Raw: envelope.Event}
+	if err := json.Unmarshal(envelope.Event, e); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+	rc.mu.RLock()
+	fn, ok := rc.events[e.Type]
+	rc.mu.RUnlock()
+	if !ok {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err := fn(r.Context(), e); err != nil {
+		rc.opt.Logger.Error("slack event handler failed", logger.Platform("slack"), logger.F("event", e.Type), logger.Err(err))
+		http.Error(w, "handler failed", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type blockActionsPayload struct {
+	Type string `json:"type"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Channel struct {
+		ID string `json:"id"`
+	} `json:"channel"`
+	Container struct {
+		MessageTS string `json:"message_ts"`
+	} `json:"container"`
+	ResponseURL string `json:"response_url"`
+	TriggerID   string `json:"trigger_id"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		BlockID  string `json:"block_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+func (rc *Receiver) serveInteraction(w http.ResponseWriter, r *http.Request, payload []byte) {
+	p := &blockActionsPayload{}
+	if err := json.Unmarshal(payload, p); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	if p.Type != "block_actions" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	var replies []string
+	for _, act := range p.Actions {
+		a := &BlockAction{
+			ActionID:    act.ActionID,
+			BlockID:     act.BlockID,
+			Value:       act.Value,
+			UserID:      p.User.ID,
+			UserName:    p.User.Username,
+			ChannelID:   p.Channel.ID,
+			MessageTS:   p.Container.MessageTS,
+			ResponseURL: p.ResponseURL,
+			TriggerID:   p.TriggerID,
+			Payload:     payload,
+		}
+		rc.mu.RLock()
+		fn, ok := rc.actions[a.ActionID]
+		if !ok {
+			fn, ok = rc.actions[""]
+		}
+		rc.mu.RUnlock()
+		var reply string
+		err := ErrNoHandler
+		if ok {
+			reply, err = fn(r.Context(), a)
+		}
+		if errors.Is(err, ErrNoHandler) {
+			rc.opt.Logger.Warn("slack action without handler", logger.Platform("slack"), logger.F("action", a.ActionID))
+			http.Error(w, ErrNoHandler.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			rc.opt.Logger.Error("slack action handler failed", logger.Platform("slack"), logger.F("action", a.ActionID), logger.Err(err))
+			http.Error(w, "handler failed", http.StatusInternalServerError)
+			return
+		}
+		if reply != "" {
+			replies = append(replies, reply)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+	if len(replies) > 0 && p.ResponseURL != "" {
+		// the reply follows the acknowledgement Slack waits for
+		go rc.respond(p.ResponseURL, strings.Join(replies, "\n"))
+	}
+}
+
+func (rc *Receiver) serveCommand(w http.ResponseWriter, r *http.Request, form url.Values) {
+	c := &SlashCommand{
+		Command:     form.Get("command"),
+		Text:        form.Get("text"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		ChannelID:   form.Get("channel_id"),
+		ChannelName: form.Get("channel_name"),
+		TeamID:      form.Get("team_id"),
+		ResponseURL: form.Get("response_url"),
+		TriggerID:   form.Get("trigger_id"),
+	}
+	rc.mu.RLock()
+	fn, ok := rc.commands[c.Command]
+	rc.mu.RUnlock()
+	if !ok {
+		rc.opt.Logger.Warn("slack command without handler", logger.Platform("slack"), logger.F("command", c.Command))
+		http.Error(w, ErrNoHandler.Error(), http.StatusNotFound)
+		return
+	}
+	reply, err := fn(r.Context(), c)
+	if err != nil {
+		rc.opt.Logger.Error("slack command handler failed", logger.Platform("slack"), logger.F("command", c.Command), logger.Err(err))
+		// shown to the user, who would otherwise see a timeout
+		reply = "Sorry, " + c.Command + " failed."
+	}
+	if reply == "" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"response_type": "ephemeral",
+		"text":          reply,
+	})
+}
+
+// respond posts text to the response_url of an interaction, visible only to
+// the user.
+func (rc *Receiver) respond(responseURL, text string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	body, _ := json.Marshal(map[string]interface{}{
+		"text":             text,
+		"response_type":    "ephemeral",
+		"replace_original": false,
+	})
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		rc.opt.Logger.Warn("slack response failed", logger.Platform("slack"), logger.Err(err))
+		return
+	}
+	resp.Body.Close()
+}
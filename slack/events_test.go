@@ -0,0 +1,99 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func signedRequest(secret, contentType, body string) *http.Request {
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+	r := httptest.NewRequest(http.MethodPost, "/slack", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Set("X-Slack-Request-Timestamp", ts)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+func TestReceiver(t *testing.T) {
+	rc := NewReceiver(ReceiverOptions{SigningSecret: testSigningSecret, Logger: logger.Nop()})
+	var got []string
+	rc.HandleAction("ack", func(ctx context.Context, a *BlockAction) (string, error) {
+		got = append(got, "action "+a.Value+" by "+a.UserID)
+		return "", nil
+	})
+	rc.HandleAction("", func(ctx context.Context, a *BlockAction) (string, error) {
+		if a.ActionID != "snooze" {
+			return "", ErrNoHandler
+		}
+		got = append(got, "other action "+a.ActionID)
+		return "", nil
+	})
+	rc.HandleCommand("/ack", func(ctx context.Context, c *SlashCommand) (string, error) {
+		got = append(got, "command "+c.Text)
+		return "Acknowledged " + c.Text, nil
+	})
+	rc.HandleEvent("message", func(ctx context.Context, e *Event) error {
+		got = append(got, "message "+e.Text+" in "+e.Channel)
+		return nil
+	})
+
+	const form = "application/x-www-form-urlencoded"
+	tests := []struct {
+		name        string
+		secret      string
+		contentType string
+		body        string
+		wantCode    int
+		wantBody    string
+	}{
+		{"forged", "forged", form, "command=/ack&text=123", http.StatusUnauthorized, ""},
+		{"url verification", testSigningSecret, "application/json",
+			`{"type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`,
+			http.StatusOK, "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"},
+		{"block action", testSigningSecret, form,
+			url.Values{"payload": {`{"type":"block_actions","user":{"id":"U1"},"actions":[{"action_id":"ack","value":"123"}]}`}}.Encode(),
+			http.StatusOK, ""},
+		{"other action", testSigningSecret, form,
+			url.Values{"payload": {`{"type":"block_actions","actions":[{"action_id":"snooze"}]}`}}.Encode(),
+			http.StatusOK, ""},
+		{"unknown action", testSigningSecret, form,
+			url.Values{"payload": {`{"type":"block_actions","actions":[{"action_id":"nope"}]}`}}.Encode(),
+			http.StatusNotFound, ""},
+		{"command", testSigningSecret, form,
+			url.Values{"command": {"/ack"}, "text": {"123"}, "user_id": {"U1"}}.Encode(),
+			http.StatusOK, `{"response_type":"ephemeral","text":"Acknowledged 123"}` + "\n"},
+		{"unknown command", testSigningSecret, form, "command=/nope", http.StatusNotFound, "no handler\n"},
+		{"event", testSigningSecret, "application/json",
+			`{"type":"event_callback","event_id":"Ev1","event":{"type":"message","channel":"C1","user":"U1","text":"ack 123","ts":"1.2"}}`,
+			http.StatusOK, ""},
+		{"unhandled event", testSigningSecret, "application/json",
+			`{"type":"event_callback","event":{"type":"reaction_added"}}`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		rc.ServeHTTP(w, signedRequest(tt.secret, tt.contentType, tt.body))
+		if w.Code != tt.wantCode || tt.wantCode == http.StatusOK && w.Body.String() != tt.wantBody {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, w.Code, w.Body.String(), tt.wantCode, tt.wantBody)
+		}
+	}
+
+	want := []string{"action 123 by U1", "other action snooze", "command 123", "message ack 123 in C1"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("handled %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// ApiBaseURL is the prefix of the Slack Web API methods used for uploads.
+var ApiBaseURL = "https://slack.com/api/"
+
+type uploadURLResp struct {
+	Resp
+	UploadURL string `json:"upload_url"`
+	FileID    string `json:"file_id"`
+}
+
+type uploadedFile struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type completeUploadResp struct {
+	Resp
+	Files []struct {
+		ID     string `json:"id"`
+		Shares map[string]map[string][]struct {
+			Ts string `json:"ts"`
+		} `json:"shares"`
+	} `json:"files"`
+}
+
+// SendAttachments uploads files to the channel with message as their
+// comment, following the files.uploadV2 flow. Uploads take channel ids,
+// so a channel given by name is resolved to its id.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the ts of the
+// message sharing the files, when Slack already tells it.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.sendAttachments(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "slack", c.opt.Channel, start, err)
+	return r, err
+}
+
+func (c *client) sendAttachments(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	if c.opt.Token == "" {
+		return nil, errors.New("missing token")
+	}
+	if IsWebhookURL(c.opt.Token) {
+		return nil, errWebhookOnly
+	}
+	if !c.opt.PostAt.IsZero() || c.opt.User != "" {
+		return nil, errors.New("files cannot be scheduled or sent as ephemeral messages")
+	}
+	if c.opt.Channel == "" {
+		return nil, errors.New("missing user")
+	}
+	if len(files) == 0 {
+		return nil, errors.New("missing attachment")
+	}
+	channel, err := c.resolver().Channel(ctx, c.opt.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if message != "" {
+		if message, err = c.resolver().Mentions(ctx, message); err != nil {
+			return nil, err
+		}
+	}
+
+	uploaded := make([]uploadedFile, 0, len(files))
+	for _, f := range files {
+		id, err := c.upload(ctx, f)
+		if err != nil {
+			return nil, fmt.Errorf("upload %s: %w", f.FileName(), err)
+		}
+		uploaded = append(uploaded, uploadedFile{ID: id, Title: f.FileName()})
+	}
+
+	fj, _ := json.Marshal(uploaded)
+	form := url.Values{
+		"files":      {string(fj)},
+		"channel_id": {channel},
+	}
+	if message != "" {
+		form.Set("initial_comment", message)
+	}
+	if c.opt.ThreadTS != "" {
+		form.Set("thread_ts", c.opt.ThreadTS)
+	}
+	var raw json.RawMessage
+	if err := c.call(ctx, "files.completeUploadExternal", form, &raw); err != nil {
+		return nil, err
+	}
+	r := &completeUploadResp{}
+	if err := json.Unmarshal(raw, r); err != nil {
+		return nil, err
+	}
+	if !r.Ok {
+		return nil, errors.New(r.Error)
+	}
+
+	rc := &receipt.Receipt{
+		Platform: "slack",
+		Channel:  channel,
+		ThreadID: c.opt.ThreadTS,
+		SentAt:   time.Now(),
+		Raw:      raw,
+	}
+	// files are shared asynchronously, the ts is only there when the
+	// sharing was done by the time Slack answered
+	for _, f := range r.Files {
+		for _, shares := range f.Shares {
+			for _, share := range shares[channel] {
+				if share.Ts != "" && rc.MessageID() == "" {
+					rc.MessageIDs = []string{share.Ts}
+					rc.SentAt = tsTime(share.Ts)
+				}
+			}
+		}
+	}
+	if rc.ThreadID == "" {
+		rc.ThreadID = rc.MessageID()
+	}
+	return rc, nil
+}
+
+// upload sends the content of f to a new upload url and returns the file id.
+func (c *client) upload(ctx context.Context, f *attachment.Attachment) (string, error) {
+	data, err := f.Bytes()
+	if err != nil {
+		return "", err
+	}
+	r := &uploadURLResp{}
+	err = c.call(ctx, "files.getUploadURLExternal", url.Values{
+		"filename": {f.FileName()},
+		"length":   {strconv.Itoa(len(data))},
+	}, r)
+	if err != nil {
+		return "", err
+	}
+	if !r.Ok {
+		return "", errors.New(r.Error)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, r.UploadURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", f.Type())
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("upload failed: %s", resp.Status)
+	}
+	return r.FileID, nil
+}
+
+// call posts form to the Web API method and decodes the response into v.
+func (c *client) call(ctx context.Context, method string, form url.Values, v interface{}) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, ApiBaseURL+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "Bearer "+c.opt.Token)
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("slack server error: %s", string(body))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+)
+
+func TestSendAttachmentsReceipt(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files.getUploadURLExternal":
+			w.Write([]byte(`{"ok":true,"upload_url":"` + srv.URL + `/upload","file_id":"F1"}`))
+		case "/files.completeUploadExternal":
+			w.Write([]byte(`{"ok":true,"files":[{"id":"F1","shares":{"public":{"C0000001":[{"ts":"1714557600.000100"}]}}}]}`))
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { ApiBaseURL = url }(ApiBaseURL)
+	ApiBaseURL = srv.URL + "/"
+
+	c := New(Options{Token: "xoxb-test", Channel: "C0000001"})
+	files := []*attachment.Attachment{attachment.New("report.txt", "text/plain", []byte("all good"))}
+	r, err := c.SendAttachmentsWithReceipt(context.Background(), "nightly report", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Channel != "C0000001" || r.MessageID() != "1714557600.000100" || r.ThreadID != r.MessageID() {
+		t.Errorf("receipt %+v", r)
+	}
+}
@@ -1,8 +1,19 @@
 package slack
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 	"github.com/imroc/req"
 )
 
@@ -12,49 +23,280 @@ const (
 
 // Options allows full configuration of the message sent to the Pushover API
 type Options struct {
+	// Token is a bot token, or an incoming webhook url to post through it.
 	Token   string `json:"token"`
 	Channel string `json:"channel"`
 	Text    string `json:"text"`
+	// Username, IconEmoji and IconURL override the name and icon of the
+	// poster, with a bot token this needs the chat:write.customize scope.
+	Username  string `json:"username,omitempty"`
+	IconEmoji string `json:"icon_emoji,omitempty"`
+	IconURL   string `json:"icon_url,omitempty"`
+	// ThreadTS posts the message as a reply in the thread of that message.
+	ThreadTS string `json:"thread_ts,omitempty"`
+	// PostAt schedules the message with chat.scheduleMessage, the receipt
+	// then has the scheduled message id to delete it with.
+	PostAt time.Time `json:"-"`
+	// User posts an ephemeral message, only shown to that user in the
+	// channel, with chat.postEphemeral. It may be an "@handle" or an email
+	// address, see Resolver.
+	User string `json:"user,omitempty"`
+	// Blocks lay out the message, the text is then the fallback shown in
+	// notifications.
+	Blocks *Blocks `json:"-"`
+	// Actions are shown as buttons below the text.
+	Actions []action.Action `json:"-"`
+	// Color is the bar beside the message, as "#rrggbb".
+	Color string `json:"-"`
+	// Resolver looks up the channel when it is an "@handle" or an email
+	// address, and the {{mention "target"}} in the text. The clients of a
+	// token share one unless it is set.
+	Resolver *Resolver `json:"-"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the bot token or webhook url in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
+	opt      Options
+	redactor *redact.Redactor
 }
 
 func New(opt Options) *client {
-	return &client{opt: opt}
+	r := redact.New(opt.Token)
+	opt.Logger = r.Logger(opt.Logger)
+	return &client{opt: opt, redactor: r}
 }
 
 type Resp struct {
-	Ok    bool   `json:"ok"`
-	Error string `json:"error"`
+	Ok      bool   `json:"ok"`
+	Error   string `json:"error"`
+	Ts      string `json:"ts"`
+	Channel string `json:"channel"`
+	// scheduled messages
+	ScheduledMessageID string `json:"scheduled_message_id"`
+	PostAt             int64  `json:"post_at"`
+	// ephemeral messages
+	MessageTs string `json:"message_ts"`
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns its channel id and ts.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "slack", c.opt.Channel, start, err)
+	return r, err
+}
+
+func (c *client) send(ctx context.Context, message string) (*receipt.Receipt, error) {
 	if c.opt.Token == "" {
-		return errors.New("missing token")
+		return nil, errors.New("missing token")
+	}
+	if message == "" {
+		return nil, errors.New("missing message")
+	}
+	message, err := c.resolver().Mentions(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	if !c.opt.PostAt.IsZero() || c.opt.User != "" {
+		if err := c.checkDeferred(); err != nil {
+			return nil, err
+		}
+	}
+	if IsWebhookURL(c.opt.Token) {
+		return c.sendWebhook(ctx, message)
 	}
 	if c.opt.Channel == "" {
-		return errors.New("missing user")
+		return nil, errors.New("missing user")
 	}
-	if message == "" {
-		return errors.New("missing message")
+	if c.opt.User != "" {
+		if c.opt.User, err = c.resolver().User(ctx, c.opt.User); err != nil {
+			return nil, err
+		}
+	}
+	// chat.postMessage takes channel names but only ids for direct messages
+	if strings.HasPrefix(c.opt.Channel, "@") || isEmail(c.opt.Channel) {
+		if c.opt.Channel, err = c.resolver().Channel(ctx, c.opt.Channel); err != nil {
+			return nil, err
+		}
 	}
 	c.opt.Text = message
-	inrec, _ := json.Marshal(c.opt)
+	inrec, _ := json.Marshal(options(c.opt))
 	params := &req.Param{}
 	json.Unmarshal(inrec, params)
-	resp, err := req.Post(ApiURL, *params)
+	content, err := c.content(message)
 	if err != nil {
-		return nil
+		return nil, err
+	}
+	delete(*params, "text")
+	for k, v := range content {
+		(*params)[k] = v
+	}
+	apiURL := ApiURL
+	switch {
+	case !c.opt.PostAt.IsZero():
+		(*params)["post_at"] = c.opt.PostAt.Unix()
+		apiURL = ApiBaseURL + "chat.scheduleMessage"
+	case c.opt.User != "":
+		apiURL = ApiBaseURL + "chat.postEphemeral"
+	}
+	resp, err := req.Post(apiURL, *params, ctx, tracing.HTTPClient)
+	if err != nil {
+		return nil, err
 	}
 	r := &Resp{}
 	err = resp.ToJSON(r)
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	if !r.Ok {
-		return errors.New(r.Error)
+		return nil, errors.New(r.Error)
+	}
+	switch {
+	case r.ScheduledMessageID != "":
+		return &receipt.Receipt{
+			Platform:   "slack",
+			Channel:    r.Channel,
+			MessageIDs: []string{r.ScheduledMessageID},
+			ThreadID:   c.opt.ThreadTS,
+			SentAt:     time.Unix(r.PostAt, 0),
+			Raw:        receipt.JSON(resp.Bytes()),
+		}, nil
+	case r.MessageTs != "":
+		// ephemeral messages are not kept, so they cannot start a thread
+		return &receipt.Receipt{
+			Platform:   "slack",
+			Channel:    c.opt.Channel,
+			MessageIDs: []string{r.MessageTs},
+			ThreadID:   c.opt.ThreadTS,
+			SentAt:     tsTime(r.MessageTs),
+			Raw:        receipt.JSON(resp.Bytes()),
+		}, nil
+	}
+	threadTS := c.opt.ThreadTS
+	if threadTS == "" {
+		threadTS = r.Ts
+	}
+	return &receipt.Receipt{
+		Platform:   "slack",
+		Channel:    r.Channel,
+		MessageIDs: []string{r.Ts},
+		ThreadID:   threadTS,
+		SentAt:     tsTime(r.Ts),
+		Raw:        receipt.JSON(resp.Bytes()),
+	}, nil
+}
+
+// checkDeferred checks the options of a scheduled or ephemeral message.
+func (c *client) checkDeferred() error {
+	if IsWebhookURL(c.opt.Token) {
+		return errWebhookOnly
+	}
+	if !c.opt.PostAt.IsZero() {
+		if c.opt.User != "" {
+			return errors.New("ephemeral messages cannot be scheduled")
+		}
+		if ahead := time.Until(c.opt.PostAt); ahead <= 0 || ahead > MaxScheduleAhead {
+			return fmt.Errorf("post time %s is not in the next %d days",
+				c.opt.PostAt.Format(time.RFC3339), MaxScheduleAhead/(24*time.Hour))
+		}
 	}
 	return nil
 }
+
+func (c *client) resolver() *Resolver {
+	if c.opt.Resolver != nil {
+		return c.opt.Resolver
+	}
+	return sharedResolver(c.opt.Token)
+}
+
+// tsTime returns the time of a message ts, "seconds.microseconds", or now
+// if ts is not one.
+func tsTime(ts string) time.Time {
+	parts := strings.SplitN(ts, ".", 2)
+	sec, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	var usec int64
+	if len(parts) == 2 {
+		usec, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+	return time.Unix(sec, usec*int64(time.Microsecond))
+}
+
+// content returns the text, attachments and blocks fields showing message
+// with the color and actions of the options.
+func (c *client) content(message string) (map[string]string, error) {
+	var blocks *Blocks
+	if c.opt.Blocks != nil {
+		blocks = NewBlocks().Add(c.opt.Blocks.Blocks()...)
+		if len(c.opt.Actions) > 0 {
+			blocks.Actions(c.opt.Actions...)
+		}
+	} else if len(c.opt.Actions) > 0 {
+		blocks = actionBlocks(message, c.opt.Actions)
+	}
+	if blocks != nil {
+		if err := blocks.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	if c.opt.Color != "" {
+		// only attachments have a color bar, the text moves into one
+		attachment := map[string]interface{}{
+			"color":    c.opt.Color,
+			"fallback": message,
+		}
+		if blocks != nil {
+			attachment["blocks"] = blocks
+		} else {
+			attachment["text"] = message
+		}
+		aj, err := json.Marshal([]interface{}{attachment})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"attachments": string(aj)}, nil
+	}
+	fields := map[string]string{"text": message}
+	if blocks != nil {
+		bj, err := json.Marshal(blocks)
+		if err != nil {
+			return nil, err
+		}
+		fields["blocks"] = string(bj)
+	}
+	return fields, nil
+}
@@ -0,0 +1,342 @@
+package slack
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver turns "#channel" names, "@handle"s and email addresses into the
+// ids the Web API takes, caching what it looked up for ResolverTTL. It
+// needs the channels:read, groups:read, users:read, users:read.email and
+// im:write scopes for the lookups it makes.
+type Resolver struct {
+	c *client
+
+	mu       sync.Mutex
+	users    *idCache
+	channels *idCache
+	dms      *idCache
+}
+
+// NewResolver returns a Resolver looking up with the bot token.
+func NewResolver(token string) *Resolver {
+	return &Resolver{
+		c:        New(Options{Token: token}),
+		users:    newIDCache(),
+		channels: newIDCache(),
+		dms:      newIDCache(),
+	}
+}
+
+const (
+	// ResolverTTL is how long a Resolver keeps the ids it looked up, so
+	// renamed channels and deactivated users are noticed.
+	ResolverTTL = time.Hour
+	// maxIDs is how many ids a cache of a Resolver holds, those that
+	// expired are dropped beyond, or all of them when none has.
+	maxIDs = 4096
+	// maxResolvers is how many tokens have a shared Resolver, the least
+	// recently used is forgotten beyond.
+	maxResolvers = 64
+)
+
+type cachedID struct {
+	id      string
+	expires time.Time
+}
+
+// idCache maps names to ids for ResolverTTL, guarded by Resolver.mu.
+type idCache struct {
+	ids map[string]cachedID
+}
+
+func newIDCache() *idCache {
+	return &idCache{ids: make(map[string]cachedID)}
+}
+
+func (c *idCache) get(key string) (string, bool) {
+	v, ok := c.ids[key]
+	if !ok || time.Now().After(v.expires) {
+		return "", false
+	}
+	return v.id, true
+}
+
+func (c *idCache) put(key, id string) {
+	now := time.Now()
+	if _, ok := c.ids[key]; !ok && len(c.ids) >= maxIDs {
+		for k, v := range c.ids {
+			if now.After(v.expires) {
+				delete(c.ids, k)
+			}
+		}
+		if len(c.ids) >= maxIDs {
+			c.ids = make(map[string]cachedID)
+		}
+	}
+	c.ids[key] = cachedID{id: id, expires: now.Add(ResolverTTL)}
+}
+
+type sharedEntry struct {
+	r    *Resolver
+	used time.Time
+}
+
+var (
+	resolversMu sync.Mutex
+	resolvers   = map[[sha256.Size]byte]*sharedEntry{}
+)
+
+// sharedResolver returns the Resolver of token, so the clients created for
+// each message share its cache.
+func sharedResolver(token string) *Resolver {
+	key := sha256.Sum256([]byte(token))
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	e, ok := resolvers[key]
+	if !ok {
+		if len(resolvers) >= maxResolvers {
+			var oldest [sha256.Size]byte
+			var oldestUsed time.Time
+			for k, v := range resolvers {
+				if oldestUsed.IsZero() || v.used.Before(oldestUsed) {
+					oldest, oldestUsed = k, v.used
+				}
+			}
+			delete(resolvers, oldest)
+		}
+		e = &sharedEntry{r: NewResolver(token)}
+		resolvers[key] = e
+	}
+	e.used = time.Now()
+	return e.r
+}
+
+var slackID = regexp.MustCompile(`^[CDGUW][A-Z0-9]{6,}$`)
+
+func isEmail(target string) bool {
+	return strings.Index(target, "@") > 0
+}
+
+// isUser reports whether target is an "@handle", email address or user id.
+func isUser(target string) bool {
+	return strings.HasPrefix(target, "@") || isEmail(target) ||
+		slackID.MatchString(target) && (target[0] == 'U' || target[0] == 'W')
+}
+
+// User returns the id of the user with the "@handle" or email address
+// target, a user id is returned as is.
+func (r *Resolver) User(ctx context.Context, target string) (string, error) {
+	if !isUser(target) {
+		return "", fmt.Errorf("%q is not a user", target)
+	}
+	if slackID.MatchString(target) {
+		return target, nil
+	}
+	key := strings.ToLower(target)
+	r.mu.Lock()
+	id, ok := r.users.get(key)
+	r.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	var err error
+	if isEmail(target) {
+		id, err = r.lookupByEmail(ctx, target)
+	} else {
+		id, err = r.lookupHandle(ctx, strings.TrimPrefix(key, "@"))
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolve %s: %w", target, err)
+	}
+	r.mu.Lock()
+	r.users.put(key, id)
+	r.mu.Unlock()
+	return id, nil
+}
+
+// Channel returns the id of the conversation to post target to: the
+// direct message channel of the "@handle", email address or user id, else
+// the channel of that name, with or without "#". A conversation id is
+// returned as is.
+func (r *Resolver) Channel(ctx context.Context, target string) (string, error) {
+	if !isUser(target) {
+		if slackID.MatchString(target) {
+			return target, nil
+		}
+		return r.channel(ctx, strings.ToLower(strings.TrimPrefix(target, "#")))
+	}
+
+	user, err := r.User(ctx, target)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	id, ok := r.dms.get(user)
+	r.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+	resp := &struct {
+		Resp
+		Channel struct {
+			ID string `json:"id"`
+		} `json:"channel"`
+	}{}
+	if err := r.call(ctx, "conversations.open", url.Values{"users": {user}}, resp); err != nil {
+		return "", fmt.Errorf("resolve %s: %w", target, err)
+	}
+	r.mu.Lock()
+	r.dms.put(user, resp.Channel.ID)
+	r.mu.Unlock()
+	return resp.Channel.ID, nil
+}
+
+func (r *Resolver) channel(ctx context.Context, name string) (string, error) {
+	r.mu.Lock()
+	id, ok := r.channels.get(name)
+	r.mu.Unlock()
+	if ok {
+		return id, nil
+	}
+
+	// every page is cached, the next names are likely in it
+	form := url.Values{
+		"types":            {"public_channel,private_channel"},
+		"exclude_archived": {"true"},
+		"limit":            {"1000"},
+	}
+	for {
+		resp := &struct {
+			Resp
+			Channels []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channels"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}{}
+		if err := r.call(ctx, "conversations.list", form, resp); err != nil {
+			return "", fmt.Errorf("resolve #%s: %w", name, err)
+		}
+		r.mu.Lock()
+		for _, ch := range resp.Channels {
+			r.channels.put(strings.ToLower(ch.Name), ch.ID)
+		}
+		id, ok = r.channels.get(name)
+		r.mu.Unlock()
+		if ok {
+			return id, nil
+		}
+		if resp.Metadata.NextCursor == "" {
+			return "", fmt.Errorf("channel #%s not found", name)
+		}
+		form.Set("cursor", resp.Metadata.NextCursor)
+	}
+}
+
+func (r *Resolver) lookupByEmail(ctx context.Context, email string) (string, error) {
+	resp := &struct {
+		Resp
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}{}
+	if err := r.call(ctx, "users.lookupByEmail", url.Values{"email": {email}}, resp); err != nil {
+		return "", err
+	}
+	return resp.User.ID, nil
+}
+
+// lookupHandle pages through users.list for the user named handle, there
+// is no lookup by name.
+func (r *Resolver) lookupHandle(ctx context.Context, handle string) (string, error) {
+	form := url.Values{"limit": {"1000"}}
+	for {
+		resp := &struct {
+			Resp
+			Members []struct {
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Deleted bool   `json:"deleted"`
+				Profile struct {
+					DisplayName string `json:"display_name"`
+				} `json:"profile"`
+			} `json:"members"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}{}
+		if err := r.call(ctx, "users.list", form, resp); err != nil {
+			return "", err
+		}
+		for _, m := range resp.Members {
+			if !m.Deleted && (strings.EqualFold(m.Name, handle) || strings.EqualFold(m.Profile.DisplayName, handle)) {
+				return m.ID, nil
+			}
+		}
+		if resp.Metadata.NextCursor == "" {
+			return "", errors.New("user not found")
+		}
+		form.Set("cursor", resp.Metadata.NextCursor)
+	}
+}
+
+// call is client.call failing on responses that are not ok.
+func (r *Resolver) call(ctx context.Context, method string, form url.Values, v interface {
+	ok() (bool, string)
+}) error {
+	if IsWebhookURL(r.c.opt.Token) {
+		return errWebhookOnly
+	}
+	if err := r.c.call(ctx, method, form, v); err != nil {
+		return err
+	}
+	if ok, e := v.ok(); !ok {
+		return errors.New(e)
+	}
+	return nil
+}
+
+func (r *Resp) ok() (bool, string) {
+	return r.Ok, r.Error
+}
+
+var mention = regexp.MustCompile(`\{\{\s*mention\s+"([^"]*)"\s*\}\}`)
+
+// Mentions replaces every {{mention "target"}} in text by the mention of
+// the user or channel target, e.g. {{mention "ops@acme.io"}} by <@U123>.
+// "here", "channel" and "everyone" notify the members of the channel.
+func (r *Resolver) Mentions(ctx context.Context, text string) (string, error) {
+	var err error
+	text = mention.ReplaceAllStringFunc(text, func(m string) string {
+		if err != nil {
+			return m
+		}
+		target := mention.FindStringSubmatch(m)[1]
+		switch strings.TrimPrefix(target, "@") {
+		case "here", "channel", "everyone":
+			return "<!" + strings.TrimPrefix(target, "@") + ">"
+		}
+		var id string
+		if !isUser(target) {
+			id, err = r.Channel(ctx, target)
+			return "<#" + id + ">"
+		}
+		id, err = r.User(ctx, target)
+		return "<@" + id + ">"
+	})
+	if err != nil {
+		return "", err
+	}
+	return text, nil
+}
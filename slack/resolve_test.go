@@ -0,0 +1,93 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestResolver(t *testing.T) {
+	calls := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		calls[r.URL.Path]++
+		switch r.URL.Path {
+		case "/users.lookupByEmail":
+			if r.Form.Get("email") != "ops@acme.io" {
+				w.Write([]byte(`{"ok":false,"error":"users_not_found"}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"user":{"id":"U0123456"}}`))
+		case "/conversations.open":
+			w.Write([]byte(`{"ok":true,"channel":{"id":"D0123456"}}`))
+		case "/conversations.list":
+			if r.Form.Get("cursor") == "" {
+				w.Write([]byte(`{"ok":true,"channels":[{"id":"C0000001","name":"general"}],"response_metadata":{"next_cursor":"p2"}}`))
+				return
+			}
+			w.Write([]byte(`{"ok":true,"channels":[{"id":"C0000002","name":"ops"}]}`))
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { ApiBaseURL = url }(ApiBaseURL)
+	ApiBaseURL = srv.URL + "/"
+
+	ctx := context.Background()
+	r := NewResolver("xoxb-test")
+	for i := 0; i < 2; i++ {
+		text, err := r.Mentions(ctx, `{{mention "ops@acme.io"}} see {{ mention "#ops" }}, {{mention "here"}}`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "<@U0123456> see <#C0000002>, <!here>"; text != want {
+			t.Errorf("got %q, want %q", text, want)
+		}
+		dm, err := r.Channel(ctx, "ops@acme.io")
+		if err != nil || dm != "D0123456" {
+			t.Errorf("dm %q, %v", dm, err)
+		}
+	}
+	if calls["/users.lookupByEmail"] != 1 || calls["/conversations.open"] != 1 || calls["/conversations.list"] != 2 {
+		t.Errorf("lookups not cached: %v", calls)
+	}
+	if id, _ := r.Channel(ctx, "general"); id != "C0000001" {
+		t.Errorf("general is %q", id)
+	}
+
+	if _, err := r.User(ctx, "nobody@acme.io"); err == nil {
+		t.Error("resolved an unknown email")
+	}
+}
+
+func TestResolverCaches(t *testing.T) {
+	c := newIDCache()
+	c.put("#ops", "C1")
+	if id, ok := c.get("#ops"); !ok || id != "C1" {
+		t.Errorf("got %q %v", id, ok)
+	}
+	// looked up again once expired
+	c.ids["#ops"] = cachedID{id: "C1", expires: time.Now().Add(-time.Second)}
+	if _, ok := c.get("#ops"); ok {
+		t.Error("expired id returned")
+	}
+	for i := 0; i < maxIDs+10; i++ {
+		c.put(strconv.Itoa(i), "C")
+	}
+	if len(c.ids) > maxIDs {
+		t.Errorf("%d ids cached", len(c.ids))
+	}
+
+	first := sharedResolver("xoxb-first")
+	for i := 0; i < maxResolvers; i++ {
+		sharedResolver("xoxb-" + strconv.Itoa(i))
+	}
+	if len(resolvers) > maxResolvers {
+		t.Errorf("%d resolvers kept", len(resolvers))
+	}
+	if sharedResolver("xoxb-first") == first {
+		t.Error("the least recently used resolver was kept")
+	}
+}
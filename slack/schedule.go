@@ -0,0 +1,116 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+)
+
+// MaxScheduleAhead is how far ahead chat.scheduleMessage takes a PostAt.
+const MaxScheduleAhead = 120 * 24 * time.Hour
+
+// ScheduledMessage is a message waiting to be posted, see
+// Options.PostAt.
+type ScheduledMessage struct {
+	ID        string
+	Channel   string
+	Text      string
+	PostAt    time.Time
+	CreatedAt time.Time
+}
+
+// ScheduledMessages lists the messages scheduled by the bot, in the
+// channel of the options or in all channels when it is empty.
+func (c *client) ScheduledMessages(ctx context.Context) ([]ScheduledMessage, error) {
+	msgs, err := c.scheduledMessages(ctx)
+	return msgs, c.redactor.Error(err)
+}
+
+func (c *client) scheduledMessages(ctx context.Context) ([]ScheduledMessage, error) {
+	if c.opt.Token == "" {
+		return nil, errors.New("missing token")
+	}
+	if IsWebhookURL(c.opt.Token) {
+		return nil, errWebhookOnly
+	}
+	form := url.Values{"limit": {"100"}}
+	if c.opt.Channel != "" {
+		channel, err := c.resolver().Channel(ctx, c.opt.Channel)
+		if err != nil {
+			return nil, err
+		}
+		form.Set("channel", channel)
+	}
+
+	var msgs []ScheduledMessage
+	for {
+		resp := &struct {
+			Resp
+			Messages []struct {
+				ID          string `json:"id"`
+				ChannelID   string `json:"channel_id"`
+				Text        string `json:"text"`
+				PostAt      int64  `json:"post_at"`
+				DateCreated int64  `json:"date_created"`
+			} `json:"scheduled_messages"`
+			Metadata struct {
+				NextCursor string `json:"next_cursor"`
+			} `json:"response_metadata"`
+		}{}
+		if err := c.call(ctx, "chat.scheduledMessages.list", form, resp); err != nil {
+			return nil, err
+		}
+		if !resp.Ok {
+			return nil, errors.New(resp.Error)
+		}
+		for _, m := range resp.Messages {
+			msgs = append(msgs, ScheduledMessage{
+				ID:        m.ID,
+				Channel:   m.ChannelID,
+				Text:      m.Text,
+				PostAt:    time.Unix(m.PostAt, 0),
+				CreatedAt: time.Unix(m.DateCreated, 0),
+			})
+		}
+		if resp.Metadata.NextCursor == "" {
+			return msgs, nil
+		}
+		form.Set("cursor", resp.Metadata.NextCursor)
+	}
+}
+
+// DeleteScheduled deletes the scheduled message id from the channel, given
+// by its id, before it is posted.
+func (c *client) DeleteScheduled(ctx context.Context, channel, id string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.deleteScheduled(ctx, channel, id))
+	logger.Delivery(ctx, c.opt.Logger, "slack", channel, start, err)
+	return err
+}
+
+func (c *client) deleteScheduled(ctx context.Context, channel, id string) error {
+	if c.opt.Token == "" {
+		return errors.New("missing token")
+	}
+	if IsWebhookURL(c.opt.Token) {
+		return errWebhookOnly
+	}
+	if channel == "" || id == "" {
+		return errors.New("missing message id")
+	}
+	resp := &Resp{}
+	err := c.call(ctx, "chat.deleteScheduledMessage", url.Values{
+		"channel":              {channel},
+		"scheduled_message_id": {id},
+	}, resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
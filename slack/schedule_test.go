@@ -0,0 +1,47 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestScheduledMessages(t *testing.T) {
+	deleted := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/chat.scheduledMessages.list":
+			w.Write([]byte(`{"ok":true,"scheduled_messages":[{"id":"Q1298393284","channel_id":"C1H9RESGL","post_at":1562180400,"date_created":1562177699,"text":"maintenance at 8"}]}`))
+		case "/chat.deleteScheduledMessage":
+			deleted = r.Form.Get("channel") + "/" + r.Form.Get("scheduled_message_id")
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer srv.Close()
+	defer func(url string) { ApiBaseURL = url }(ApiBaseURL)
+	ApiBaseURL = srv.URL + "/"
+
+	ctx := context.Background()
+	c := New(Options{Token: "xoxb-test", Channel: "C1H9RESGL"})
+	msgs, err := c.ScheduledMessages(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "Q1298393284" || !msgs[0].PostAt.Equal(time.Unix(1562180400, 0)) {
+		t.Fatalf("got %+v", msgs)
+	}
+	if err := c.DeleteScheduled(ctx, msgs[0].Channel, msgs[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "C1H9RESGL/Q1298393284" {
+		t.Errorf("deleted %q", deleted)
+	}
+
+	c = New(Options{Token: "xoxb-test", Channel: "C1H9RESGL", PostAt: time.Now().Add(200 * 24 * time.Hour)})
+	if err := c.Send("too late"); err == nil {
+		t.Error("scheduled past the limit")
+	}
+}
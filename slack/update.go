@@ -0,0 +1,62 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// Update replaces the text of the message in r with chat.update, the color
+// and buttons are those of the options so buttons not given are removed.
+func (c *client) Update(ctx context.Context, r *receipt.Receipt, message string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.update(ctx, r, message))
+	logger.Delivery(ctx, c.opt.Logger, "slack", r.Channel, start, err)
+	return err
+}
+
+func (c *client) update(ctx context.Context, r *receipt.Receipt, message string) error {
+	if c.opt.Token == "" {
+		return errors.New("missing token")
+	}
+	if IsWebhookURL(c.opt.Token) {
+		return errWebhookOnly
+	}
+	if r == nil || r.Channel == "" || r.MessageID() == "" {
+		return errors.New("missing message id")
+	}
+	if message == "" {
+		return errors.New("missing message")
+	}
+
+	message, err := c.resolver().Mentions(ctx, message)
+	if err != nil {
+		return err
+	}
+	content, err := c.content(message)
+	if err != nil {
+		return err
+	}
+	// attachments and blocks left out would be kept from the old message
+	form := url.Values{
+		"channel":     {r.Channel},
+		"ts":          {r.MessageID()},
+		"attachments": {"[]"},
+		"blocks":      {"[]"},
+	}
+	for k, v := range content {
+		form.Set(k, v)
+	}
+	resp := &Resp{}
+	if err := c.call(ctx, "chat.update", form, resp); err != nil {
+		return err
+	}
+	if !resp.Ok {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// WebhookPrefix starts the incoming webhook urls, a Token starting with it
+// is posted to as a webhook rather than through the Web API.
+var WebhookPrefix = "https://hooks.slack.com/"
+
+// IsWebhookURL reports whether token is an incoming webhook url.
+func IsWebhookURL(token string) bool {
+	return strings.HasPrefix(token, WebhookPrefix)
+}
+
+// WebhookError is the error code an incoming webhook answers with, it
+// matches the Err values of the same code with errors.Is.
+// https://api.slack.com/messaging/webhooks#handling_errors
+type WebhookError struct {
+	StatusCode int
+	Code       string
+}
+
+func (e *WebhookError) Error() string {
+	return "slack webhook error: " + e.Code
+}
+
+func (e *WebhookError) Is(target error) bool {
+	t, ok := target.(*WebhookError)
+	return ok && t.Code == e.Code
+}
+
+var (
+	ErrInvalidPayload         = &WebhookError{Code: "invalid_payload"}
+	ErrUserNotFound           = &WebhookError{Code: "user_not_found"}
+	ErrChannelNotFound        = &WebhookError{Code: "channel_not_found"}
+	ErrChannelIsArchived      = &WebhookError{Code: "channel_is_archived"}
+	ErrActionProhibited       = &WebhookError{Code: "action_prohibited"}
+	ErrPostingToGeneralDenied = &WebhookError{Code: "posting_to_general_channel_denied"}
+	ErrTooManyAttachments     = &WebhookError{Code: "too_many_attachments"}
+	ErrNoService              = &WebhookError{Code: "no_service"}
+	ErrNoServiceID            = &WebhookError{Code: "no_service_id"}
+	ErrNoTeam                 = &WebhookError{Code: "no_team"}
+	ErrTeamDisabled           = &WebhookError{Code: "team_disabled"}
+	ErrInvalidToken           = &WebhookError{Code: "invalid_token"}
+)
+
+// errWebhookOnly is returned by the methods an incoming webhook cannot do.
+var errWebhookOnly = errors.New("incoming webhooks can only post messages, a bot token is needed")
+
+// sendWebhook posts message to the incoming webhook in Token, whose
+// channel is fixed when the webhook is created. Webhooks do not answer the
+// ts of the message, so the receipt has no ids.
+func (c *client) sendWebhook(ctx context.Context, message string) (*receipt.Receipt, error) {
+	content, err := c.content(message)
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{}
+	for k, v := range content {
+		if k == "text" {
+			payload[k] = v
+		} else {
+			payload[k] = json.RawMessage(v)
+		}
+	}
+	for k, v := range map[string]string{
+		"username":   c.opt.Username,
+		"icon_emoji": c.opt.IconEmoji,
+		"icon_url":   c.opt.IconURL,
+		"thread_ts":  c.opt.ThreadTS,
+	} {
+		if v != "" {
+			payload[k] = v
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.opt.Token, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := tracing.HTTPClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	rb, _ := ioutil.ReadAll(resp.Body)
+	answer := strings.TrimSpace(string(rb))
+	if resp.StatusCode != http.StatusOK || answer != "ok" {
+		if answer == "" {
+			return nil, fmt.Errorf("slack webhook error: %s", resp.Status)
+		}
+		return nil, &WebhookError{StatusCode: resp.StatusCode, Code: answer}
+	}
+	return &receipt.Receipt{
+		Platform: "slack",
+		Channel:  c.opt.Channel,
+		ThreadID: c.opt.ThreadTS,
+		SentAt:   time.Now(),
+	}, nil
+}
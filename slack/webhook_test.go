@@ -0,0 +1,48 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhook(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		if got["text"] == "archived" {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte("channel_is_archived"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+	defer func(prefix string) { WebhookPrefix = prefix }(WebhookPrefix)
+	WebhookPrefix = srv.URL
+
+	c := New(Options{
+		Token:     srv.URL + "/services/T0/B0/secret",
+		Username:  "alerts",
+		IconEmoji: ":fire:",
+		Blocks:    NewBlocks().Section("*disk full*"),
+	})
+	if _, err := c.SendWithReceipt(context.Background(), "disk full"); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "disk full" || got["username"] != "alerts" || got["icon_emoji"] != ":fire:" {
+		t.Errorf("payload %v", got)
+	}
+	if blocks, _ := got["blocks"].([]interface{}); len(blocks) != 1 {
+		t.Errorf("blocks %v", got["blocks"])
+	}
+
+	err := c.Send("archived")
+	var werr *WebhookError
+	if !errors.Is(err, ErrChannelIsArchived) || !errors.As(err, &werr) || werr.StatusCode != http.StatusGone {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,296 @@
+package notify
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/ChainbotAI/go-notify/markdown"
+)
+
+// LongMessageMode decides what happens to a message longer than its
+// platform accepts.
+type LongMessageMode string
+
+const (
+	// LongMessageAuto truncates PagerDuty summaries and splits everything else.
+	LongMessageAuto LongMessageMode = ""
+	// LongMessageSplit sends the message as numbered parts, split on line
+	// boundaries.
+	LongMessageSplit LongMessageMode = "split"
+	// LongMessageTruncate cuts the message and ends it with an ellipsis.
+	LongMessageTruncate LongMessageMode = "truncate"
+	// LongMessageAttach truncates the message and attaches its full text as
+	// a file, on platforms that accept text files.
+	LongMessageAttach LongMessageMode = "attach"
+)
+
+// maxLength is the longest text each platform accepts, platforms missing
+// here have no practical limit.
+var maxLength = map[Platform]int{
+	PlatformTelegram:  4096,
+	PlatformDiscord:   2000,
+	PlatformSlack:     40000,
+	PlatformPagerduty: 1024,
+	PlatformPushover:  1024,
+	PlatformDingTalk:  20000,
+	PlatformLark:      30000,
+}
+
+const ellipsis = "…"
+
+// messageLimit returns the length limit for m, or 0 when it is not limited.
+func (c *Config) messageLimit(m *Message) int {
+	switch c.Platform {
+	case PlatformEmail, PlatformSes:
+		return 0
+	case PlatformLark:
+		// posts are sent as a whole
+		if m.Format == FormatMarkdown {
+			return 0
+		}
+	}
+	if c.MaxLength > 0 {
+		return c.MaxLength
+	}
+	return maxLength[c.Platform]
+}
+
+// fitLength splits or truncates text to the platform's limit.
+func (c *Config) fitLength(m *Message, text string) []string {
+	limit := c.messageLimit(m)
+	if limit == 0 || textLength(c.Platform, text) <= limit {
+		return []string{text}
+	}
+
+	mode := c.LongMessage
+	if mode == LongMessageAuto {
+		mode = LongMessageSplit
+		if c.Platform == PlatformPagerduty {
+			mode = LongMessageTruncate
+		}
+	}
+	if mode == LongMessageTruncate || mode == LongMessageAttach {
+		return []string{truncateText(c.Platform, m.Format, text, limit)}
+	}
+	return splitText(c.Platform, m.Format, text, limit)
+}
+
+// textLength counts like the platform does: Telegram counts UTF-16 code
+// units, the others characters.
+func textLength(platform Platform, s string) int {
+	if platform == PlatformTelegram {
+		return len(utf16.Encode([]rune(s)))
+	}
+	return utf8.RuneCountInString(s)
+}
+
+// cutText returns the longest prefix of s no longer than limit.
+func cutText(platform Platform, s string, limit int) string {
+	n := 0
+	for i, r := range s {
+		w := 1
+		if platform == PlatformTelegram && r > 0xFFFF {
+			w = 2
+		}
+		if n+w > limit {
+			return s[:i]
+		}
+		n += w
+	}
+	return s
+}
+
+func truncateText(platform Platform, format Format, text string, limit int) string {
+	limit -= textLength(platform, ellipsis)
+	cut := cutText(platform, text, limit)
+	// prefer ending on a line boundary unless that loses most of the text
+	if i := strings.LastIndexByte(cut, '\n'); i > len(cut)/2 {
+		cut = cut[:i+1]
+	}
+	cut = cutMarkup(platform, format, cut)
+	// a trailing backslash would escape the ellipsis in markdown dialects
+	cut = strings.TrimRight(cut, `\`)
+	return cut + ellipsis
+}
+
+// cutMarkup shortens cut, a prefix of a Telegram MarkdownV2 line or text,
+// so that it does not end inside an entity or an escape, which Telegram
+// rejects. Other platforms and formats take cut as it is.
+func cutMarkup(platform Platform, format Format, cut string) string {
+	if platform != PlatformTelegram || format != FormatMarkdown {
+		return cut
+	}
+	var (
+		open []string // entity markers not closed yet
+		code bool
+		link int // 1 in the text of a link, 2 in its url
+		safe int
+	)
+	for i := 0; i < len(cut); i++ {
+		c := cut[i]
+		switch {
+		case c == '\\':
+			// the escaped character goes with its backslash
+			i++
+		case code:
+			code = c != '`'
+		case c == '`':
+			code = true
+		case link == 2:
+			if c == ')' {
+				link = 0
+			}
+		case c == '[' && link == 0:
+			link = 1
+		case c == ']' && link == 1:
+			link = 0
+			if i+1 < len(cut) && cut[i+1] == '(' {
+				link = 2
+				i++
+			}
+		case c == '*' || c == '_' || c == '~' || c == '|':
+			marker := string(c)
+			if (c == '_' || c == '|') && i+1 < len(cut) && cut[i+1] == c {
+				marker += string(c)
+				i++
+			}
+			if n := len(open); n > 0 && open[n-1] == marker {
+				open = open[:n-1]
+			} else {
+				open = append(open, marker)
+			}
+		}
+		if i < len(cut) && !code && link == 0 && len(open) == 0 && (i+1 == len(cut) || utf8.RuneStart(cut[i+1])) {
+			safe = i + 1
+		}
+	}
+	return cut[:safe]
+}
+
+// minPartSize is the shortest text a numbered part carries, below it parts
+// go without numbers.
+const minPartSize = 32
+
+// fenceClose ends a code block cut between two parts.
+const fenceClose = "\n```"
+
+// splitText splits text into parts of at most limit, each starting with its
+// number, e.g. "(2/3)". Lines are kept whole when they fit, and a fenced
+// code block cut between two parts is closed and reopened. With a limit too
+// small to number the parts they are not numbered, and too small to close
+// code blocks too they are split as they come.
+func splitText(platform Platform, format Format, text string, limit int) []string {
+	// parts are split again when there are too many for the room their
+	// numbers were given
+	for count := 99; ; {
+		size := limit - textLength(platform, partLabel(platform, format, count, count)+"\n") - len(fenceClose)
+		if size < minPartSize {
+			if size = limit - len(fenceClose); size < minPartSize {
+				return splitLines(platform, format, text, limit, false)
+			}
+			return splitLines(platform, format, text, size, true)
+		}
+		parts := splitLines(platform, format, text, size, true)
+		if len(parts) > count {
+			count = len(parts)
+			continue
+		}
+		for i := range parts {
+			parts[i] = partLabel(platform, format, i+1, len(parts)) + "\n" + parts[i]
+		}
+		return parts
+	}
+}
+
+func partLabel(platform Platform, format Format, i, n int) string {
+	label := "(" + strconv.Itoa(i) + "/" + strconv.Itoa(n) + ")"
+	if platform == PlatformTelegram && format == FormatMarkdown {
+		label = markdown.EscapeTelegram(label)
+	}
+	return label
+}
+
+// splitLines splits text into parts of at most size, closing the code
+// blocks cut between two parts when fences is set.
+func splitLines(platform Platform, format Format, text string, size int, fences bool) []string {
+	var (
+		parts    []string
+		lines    []string
+		length   int
+		fence    string // opening line of the code block being split
+		reopened int    // 1 when the part starts with a reopened fence
+	)
+	flush := func() {
+		part := strings.Join(lines, "\n")
+		if fence != "" {
+			part += fenceClose
+		}
+		parts = append(parts, part)
+		lines, length, reopened = nil, 0, 0
+		if fence != "" {
+			lines, length, reopened = []string{fence}, textLength(platform, fence), 1
+		}
+	}
+	add := func(line string) {
+		if len(lines) > 0 {
+			length++
+		}
+		lines = append(lines, line)
+		length += textLength(platform, line)
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		for {
+			room := size - length
+			if len(lines) > 0 {
+				room--
+			}
+			lineLen := textLength(platform, line)
+			if lineLen <= room {
+				break
+			}
+			// move the line to the next part if it fits there whole
+			fresh := size
+			if fence != "" {
+				fresh -= textLength(platform, fence) + 1
+			}
+			if len(lines) > reopened && lineLen <= fresh {
+				flush()
+				continue
+			}
+			// otherwise hard wrap it
+			head := cutText(platform, line, room)
+			if markup := cutMarkup(platform, format, head); markup != "" {
+				head = markup
+			}
+			if head == "" {
+				if len(lines) == 0 {
+					// not even a character fits, send it anyway
+					_, n := utf8.DecodeRuneInString(line)
+					head = line[:n]
+				} else {
+					flush()
+					continue
+				}
+			}
+			add(head)
+			flush()
+			line = line[len(head):]
+		}
+		add(line)
+
+		if fences && strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if fence == "" {
+				fence = strings.TrimSpace(line)
+			} else {
+				fence = ""
+			}
+		}
+	}
+	// an unterminated block is the text's own doing
+	fence = ""
+	flush()
+	return parts
+}
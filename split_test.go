@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFitLength_Split(t *testing.T) {
+	var lines []string
+	lines = append(lines, "panic: runtime error", "```")
+	for i := 0; i < 30; i++ {
+		lines = append(lines, "main.handler(0xc000123456, 0x1f) /app/handler.go:42 +0x1d")
+	}
+	lines = append(lines, "```")
+	text := strings.Join(lines, "\n")
+
+	cfg := &Config{Platform: PlatformDiscord, MaxLength: 500}
+	parts := cfg.fitLength(&Message{Format: FormatMarkdown}, text)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if n := textLength(PlatformDiscord, part); n > 500 {
+			t.Errorf("part %d is %d long", i+1, n)
+		}
+		if strings.Count(part, "```")%2 != 0 {
+			t.Errorf("part %d has an unbalanced code fence:\n%s", i+1, part)
+		}
+	}
+	if !strings.HasPrefix(parts[1], "(2/") {
+		t.Errorf("part is not numbered: %q", parts[1][:10])
+	}
+
+	var joined []string
+	for _, part := range parts {
+		part = part[strings.IndexByte(part, '\n')+1:]
+		part = strings.TrimPrefix(part, "```\n")
+		part = strings.TrimSuffix(part, "\n```")
+		joined = append(joined, part)
+	}
+	if got := strings.Join(joined, "\n"); got != strings.TrimSuffix(text, "\n```") {
+		t.Errorf("parts do not add up to the text:\n%s", got)
+	}
+}
+
+func TestFitLength_Truncate(t *testing.T) {
+	cfg := &Config{Platform: PlatformPagerduty}
+	text := strings.Repeat("disk full on db-1\n", 100)
+	parts := cfg.fitLength(&Message{}, text)
+	if len(parts) != 1 {
+		t.Fatalf("pagerduty summaries should be truncated, got %d parts", len(parts))
+	}
+	if n := textLength(PlatformPagerduty, parts[0]); n > 1024 || !strings.HasSuffix(parts[0], "…") {
+		t.Errorf("bad truncation to %d characters: %q", n, parts[0][len(parts[0])-20:])
+	}
+}
+
+func TestFitLength_SmallLimit(t *testing.T) {
+	cfg := &Config{Platform: PlatformDiscord, MaxLength: 40}
+	text := strings.Repeat("disk full on db-1\n", 10)
+	parts := cfg.fitLength(&Message{}, text)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if n := textLength(PlatformDiscord, part); n > 40 {
+			t.Errorf("part %d is %d long: %q", i+1, n, part)
+		}
+	}
+}
+
+func TestFitLength_TelegramMarkdown(t *testing.T) {
+	// one long line of MarkdownV2 entities and escapes
+	line := strings.Repeat(`*db\-1* is _down_ since [12:00](https://status.example.com/db\)) `, 20)
+	cfg := &Config{Platform: PlatformTelegram, MaxLength: 100}
+	parts := cfg.fitLength(&Message{Format: FormatMarkdown}, line)
+	if len(parts) < 2 {
+		t.Fatalf("expected several parts, got %d", len(parts))
+	}
+	for i, part := range parts {
+		if n := textLength(PlatformTelegram, part); n > 100 {
+			t.Errorf("part %d is %d long", i+1, n)
+		}
+		body := part[strings.IndexByte(part, '\n')+1:]
+		if cutMarkup(PlatformTelegram, FormatMarkdown, body) != body {
+			t.Errorf("part %d ends inside an entity or escape: %q", i+1, body)
+		}
+	}
+
+	truncated := truncateText(PlatformTelegram, FormatMarkdown, line, 100)
+	if body := strings.TrimSuffix(truncated, ellipsis); cutMarkup(PlatformTelegram, FormatMarkdown, body) != body {
+		t.Errorf("truncated inside an entity or escape: %q", truncated)
+	}
+}
@@ -0,0 +1,54 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ChainbotAI/go-notify/action"
+	tb "gopkg.in/telebot.v3"
+)
+
+const (
+	maxCallbackData = 64
+	maxRowButtons   = 3
+)
+
+// inlineKeyboard lays out actions as inline buttons, three per row.
+func inlineKeyboard(actions []action.Action) (*tb.ReplyMarkup, error) {
+	markup := &tb.ReplyMarkup{}
+	var row []tb.InlineButton
+	for _, a := range actions {
+		button := tb.InlineButton{Text: a.Label}
+		if a.IsLink() {
+			button.URL = a.URL
+		} else if len(a.Data()) > maxCallbackData {
+			return nil, fmt.Errorf("action %s: id and value longer than %d bytes", a.ID, maxCallbackData)
+		} else {
+			button.Data = a.Data()
+		}
+		row = append(row, button)
+		if len(row) == maxRowButtons {
+			markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
+	}
+	return markup, nil
+}
+
+// VerifyWebhook checks the X-Telegram-Bot-Api-Secret-Token header of an
+// update against the secret_token given to setWebhook.
+func VerifyWebhook(secretToken string, header http.Header) error {
+	if secretToken == "" {
+		return errors.New("missing secret token")
+	}
+	got := header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(got), []byte(secretToken)) != 1 {
+		return errors.New("invalid secret token")
+	}
+	return nil
+}
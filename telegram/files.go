@@ -0,0 +1,150 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/ChainbotAI/go-notify/attachment"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	tb "gopkg.in/telebot.v3"
+)
+
+// MaxCaptionLength is the longest caption Telegram accepts, in UTF-16 code
+// units, longer messages are sent ahead of the files.
+const MaxCaptionLength = 1024
+
+// chatName is a public chat addressed by its @username.
+type chatName string
+
+func (n chatName) Recipient() string {
+	return "@" + strings.TrimPrefix(string(n), "@")
+}
+
+// SendAttachments sends files with sendPhoto for images and sendDocument
+// otherwise, message is the caption of a single file or sent before them.
+func (c *client) SendAttachments(ctx context.Context, message string, files []*attachment.Attachment) error {
+	_, err := c.SendAttachmentsWithReceipt(ctx, message, files)
+	return err
+}
+
+// SendAttachmentsWithReceipt is SendAttachments returning the chat:message
+// ids of the message and files sent, the thread being the first message in
+// each chat.
+func (c *client) SendAttachmentsWithReceipt(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.sendAttachments(ctx, message, files)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "telegram", c.target(), start, err)
+	return r, err
+}
+
+func (c *client) sendAttachments(ctx context.Context, message string, files []*attachment.Attachment) (*receipt.Receipt, error) {
+	if c.opt.Token == "" {
+		return nil, errors.New("missing token")
+	}
+	if len(files) == 0 {
+		return nil, errors.New("missing attachment")
+	}
+
+	r := &receipt.Receipt{
+		Platform: "telegram",
+		Channel:  c.target(),
+		SentAt:   time.Now(),
+	}
+	var threads []string
+	caption := message
+	if len(files) > 1 || len(utf16.Encode([]rune(message))) > MaxCaptionLength {
+		if message != "" {
+			text, err := c.send(ctx, message)
+			if err != nil {
+				return nil, err
+			}
+			r.MessageIDs, r.SentAt = text.MessageIDs, text.SentAt
+			threads = text.MessageIDs
+		}
+		caption = ""
+	}
+
+	bot, err := tb.NewBot(tb.Settings{Token: c.opt.Token, Offline: true})
+	if err != nil {
+		return nil, err
+	}
+	// without a message ahead, the files start the thread
+	threadFiles := len(threads) == 0
+	var recipients []tb.Recipient
+	if c.opt.ChannelType == NotifyChannelTypeTgBot {
+		for _, chatID := range c.opt.ChatIDs {
+			recipients = append(recipients, tb.ChatID(chatID))
+		}
+	} else if c.opt.Channel != 0 {
+		recipients = append(recipients, tb.ChatID(c.opt.Channel))
+	} else {
+		recipients = append(recipients, chatName(c.opt.ChatName))
+	}
+
+	for _, to := range recipients {
+		opts := &tb.SendOptions{
+			ThreadID:            c.opt.TopicId,
+			ParseMode:           c.opt.ParseMode,
+			DisableNotification: c.opt.Silent,
+		}
+		chatID, _ := strconv.ParseInt(to.Recipient(), 10, 64)
+		if id := c.replyTo(chatID); id != 0 {
+			opts.ReplyTo = &tb.Message{ID: id}
+		}
+		for i, f := range files {
+			m, err := c.sendFile(ctx, bot, to, f, caption, opts)
+			if err != nil {
+				return nil, err
+			}
+			if m.Chat != nil {
+				chatID = m.Chat.ID
+			}
+			ref := messageRef(chatID, m.ID)
+			r.MessageIDs = append(r.MessageIDs, ref)
+			if i == 0 && threadFiles {
+				threads = append(threads, ref)
+			}
+		}
+	}
+	r.ThreadID = c.opt.ReplyTo
+	if r.ThreadID == "" {
+		r.ThreadID = strings.Join(threads, ",")
+	}
+	return r, nil
+}
+
+func (c *client) sendFile(ctx context.Context, bot *tb.Bot, to tb.Recipient, f *attachment.Attachment, caption string, opts *tb.SendOptions) (*tb.Message, error) {
+	r, err := f.NewReader()
+	if err != nil {
+		return nil, err
+	}
+	var what interface{}
+	method := "sendDocument"
+	if f.IsImage() {
+		method = "sendPhoto"
+		what = &tb.Photo{File: tb.FromReader(r), Caption: caption}
+	} else {
+		what = &tb.Document{File: tb.FromReader(r), Caption: caption, FileName: f.FileName(), MIME: f.Type()}
+	}
+
+	_, span := tracing.Start(ctx, "telegram "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("telegram.chat", to.Recipient())),
+	)
+	m, err := bot.Send(to, what, opts)
+	tracing.End(span, err)
+	if err != nil {
+		return nil, fmt.Errorf("%s %q: %w", method, f.FileName(), err)
+	}
+	return m, nil
+}
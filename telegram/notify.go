@@ -1,12 +1,24 @@
 package telegram
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/ChainbotAI/go-notify/action"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"github.com/ChainbotAI/go-notify/redact"
 	tgbotapi "github.com/ChainbotAI/telegram-bot-api"
-	"github.com/sirupsen/logrus"
 	"github.com/sourcegraph/conc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	tb "gopkg.in/telebot.v3"
 )
 
@@ -37,21 +49,57 @@ type Options struct {
 	ChatIDs []int64 `json:"chat_ids"`
 
 	TgBotReplyMarkup *tb.ReplyMarkup
+	// Actions are shown as an inline keyboard, TgBotReplyMarkup takes
+	// precedence in bot mode.
+	Actions []action.Action `json:"-"`
+	// ParseMode is "MarkdownV2" or "HTML" for formatted messages, plain text
+	// is sent when empty.
+	ParseMode string `json:"parse_mode"`
+	// Silent sends the message without sound.
+	Silent bool `json:"silent"`
+	// ReplyTo is the ThreadID of a receipt, the message is sent as a reply
+	// to the message it lists for each chat.
+	ReplyTo string `json:"reply_to"`
+
+	Logger logger.Logger `json:"-"`
+}
+
+// String, GoString and MarshalJSON mask the bot token in Options.
+func (o Options) String() string {
+	return o.printable().String()
+}
+
+func (o Options) GoString() string {
+	return o.printable().GoString()
+}
+
+func (o Options) MarshalJSON() ([]byte, error) {
+	return o.printable().MarshalJSON()
+}
+
+type options Options
+
+func (o Options) printable() redact.Printable {
+	o.Token = redact.Secret(o.Token)
+	return redact.Printable{V: options(o)}
 }
 
 type client struct {
-	opt Options
-	bot *tgbotapi.BotAPI
+	opt      Options
+	redactor *redact.Redactor
+	bot      *tgbotapi.BotAPI
 }
 
 func New(opt Options) *client {
+	r := redact.New(opt.Token)
+	opt.Logger = r.Logger(opt.Logger)
 	api, err := tgbotapi.NewBotAPI(opt.Token)
 	if err != nil {
-		logrus.Errorf("create tgbot api err: %v, token: %s", err, opt.Token)
+		logger.Default(opt.Logger).Error("create telegram bot api failed", logger.Platform("telegram"), logger.Err(err))
 		return nil
 	}
 
-	return &client{opt: opt, bot: api}
+	return &client{opt: opt, redactor: r, bot: api}
 }
 
 type Resp struct {
@@ -60,49 +108,156 @@ type Resp struct {
 }
 
 func (c *client) Send(message string) error {
+	return c.SendContext(context.Background(), message)
+}
+
+func (c *client) SendContext(ctx context.Context, message string) error {
+	_, err := c.SendWithReceipt(ctx, message)
+	return err
+}
+
+// SendWithReceipt sends message and returns the chat:message ids of the
+// sent messages.
+func (c *client) SendWithReceipt(ctx context.Context, message string) (*receipt.Receipt, error) {
+	start := time.Now()
+	r, err := c.send(ctx, message)
+	err = c.redactor.Error(err)
+	logger.Delivery(ctx, c.opt.Logger, "telegram", c.target(), start, err)
+	return r, err
+}
+
+func (c *client) target() string {
+	if c.opt.ChannelType == NotifyChannelTypeTgBot {
+		return "bot"
+	}
+	if c.opt.Channel != 0 {
+		return strconv.FormatInt(c.opt.Channel, 10)
+	}
+	return c.opt.ChatName
+}
+
+func (c *client) send(ctx context.Context, message string) (*receipt.Receipt, error) {
 	if c.opt.Token == "" {
-		return errors.New("missing token")
+		return nil, errors.New("missing token")
 	}
 
 	if message == "" {
-		return errors.New("missing message")
+		return nil, errors.New("missing message")
 	}
 
+	var r *receipt.Receipt
+	var err error
 	if c.opt.ChannelType == NotifyChannelTypeTgBot {
-		return c.sendTelegramBotNotify(message)
+		r, err = c.sendTelegramBotNotify(ctx, message)
 	} else {
-		return c.sendTelegramNotify(message)
+		r, err = c.sendTelegramNotify(ctx, message)
 	}
+	if err != nil {
+		return nil, err
+	}
+	r.Platform = "telegram"
+	r.Channel = c.target()
+	r.ThreadID = c.opt.ReplyTo
+	if r.ThreadID == "" {
+		r.ThreadID = strings.Join(r.MessageIDs, ",")
+	}
+	return r, nil
+}
+
+// messageRef is how receipts identify a message: "chat:message".
+func messageRef(chatID int64, messageID int) string {
+	return strconv.FormatInt(chatID, 10) + ":" + strconv.Itoa(messageID)
+}
+
+// replyTo returns the message of chatID listed in ReplyTo, or the first one
+// when chatID is 0.
+func (c *client) replyTo(chatID int64) int {
+	if c.opt.ReplyTo == "" {
+		return 0
+	}
+	for _, ref := range strings.Split(c.opt.ReplyTo, ",") {
+		i := strings.LastIndexByte(ref, ':')
+		if i < 0 {
+			continue
+		}
+		chat, _ := strconv.ParseInt(ref[:i], 10, 64)
+		if chatID == 0 || chat == chatID {
+			id, _ := strconv.Atoi(ref[i+1:])
+			return id
+		}
+	}
+	return 0
 }
 
-func (c *client) sendTelegramBotNotify(message string) error {
+// sendTelegramBotNotify sends message to every chat, the receipt lists the
+// messages that were delivered. It fails when no chat got the message.
+func (c *client) sendTelegramBotNotify(ctx context.Context, message string) (*receipt.Receipt, error) {
+	if len(c.opt.ChatIDs) == 0 {
+		return nil, errors.New("missing chat ids")
+	}
 	botToken := c.opt.Token
 	bot, err := tb.NewBot(tb.Settings{
 		Token: botToken,
 	})
 	if err != nil {
-		logrus.Errorf("[TgBot] init tg bot err: %v", err)
-		return err
+		return nil, err
+	}
+	markup := c.opt.TgBotReplyMarkup
+	if markup == nil && len(c.opt.Actions) > 0 {
+		if markup, err = inlineKeyboard(c.opt.Actions); err != nil {
+			return nil, err
+		}
 	}
-	var wg conc.WaitGroup
+	var (
+		wg   conc.WaitGroup
+		mu   sync.Mutex
+		ids  []string
+		sent []*tb.Message
+		errs []error
+	)
 	for _, chatID := range c.opt.ChatIDs {
 		chatIDObj := tb.ChatID(chatID)
-		var opts []interface{}
-		if c.opt.TgBotReplyMarkup != nil {
-			opts = append(opts, c.opt.TgBotReplyMarkup)
+		opts := &tb.SendOptions{
+			ReplyMarkup:         markup,
+			ParseMode:           c.opt.ParseMode,
+			DisableNotification: c.opt.Silent,
+		}
+		if id := c.replyTo(chatID); id != 0 {
+			opts.ReplyTo = &tb.Message{ID: id}
 		}
 
 		wg.Go(func() {
-			if _, err := bot.Send(chatIDObj, message, opts...); err != nil {
-				logrus.Errorf("[TgBot] fail to send tg bot msg, err: %v", err)
+			start := time.Now()
+			_, span := tracing.Start(ctx, "telegram sendMessage",
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attribute.Int64("telegram.chat_id", int64(chatIDObj))),
+			)
+			m, err := bot.Send(chatIDObj, message, opts)
+			tracing.End(span, err)
+			logger.Delivery(ctx, c.opt.Logger, "telegram", strconv.FormatInt(int64(chatIDObj), 10), start, err)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("chat %d: %w", int64(chatIDObj), err))
+				return
 			}
+			ids = append(ids, messageRef(int64(chatIDObj), m.ID))
+			sent = append(sent, m)
 		})
 	}
 	wg.Wait()
-	return nil
+	if len(ids) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	r := &receipt.Receipt{MessageIDs: ids, SentAt: time.Now()}
+	if len(sent) > 0 {
+		r.SentAt = sent[0].Time()
+		r.Raw, _ = json.Marshal(sent)
+	}
+	return r, nil
 }
 
-func (c *client) sendTelegramNotify(message string) error {
+func (c *client) sendTelegramNotify(ctx context.Context, message string) (*receipt.Receipt, error) {
 	var msg tgbotapi.MessageConfig
 	if c.opt.Channel != 0 {
 		if c.opt.TopicId != 0 {
@@ -113,12 +268,31 @@ func (c *client) sendTelegramNotify(message string) error {
 	} else {
 		msg = tgbotapi.NewMessageToChannel(c.opt.ChatName, message)
 	}
+	msg.ParseMode = c.opt.ParseMode
+	msg.DisableNotification = c.opt.Silent
+	msg.ReplyToMessageID = c.replyTo(0)
+	if len(c.opt.Actions) > 0 {
+		markup, err := inlineKeyboard(c.opt.Actions)
+		if err != nil {
+			return nil, err
+		}
+		msg.ReplyMarkup = markup
+	}
 
-	sendRes, err := c.bot.Send(msg)
+	_, span := tracing.Start(ctx, "telegram sendMessage", trace.WithSpanKind(trace.SpanKindClient))
+	sent, err := c.bot.Send(msg)
+	tracing.End(span, err)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	fmt.Println("sendRes:", sendRes)
-	return nil
+	chatID := c.opt.Channel
+	if sent.Chat != nil {
+		chatID = sent.Chat.ID
+	}
+	r := &receipt.Receipt{
+		MessageIDs: []string{messageRef(chatID, sent.MessageID)},
+		SentAt:     time.Unix(int64(sent.Date), 0),
+	}
+	r.Raw, _ = json.Marshal(sent)
+	return r, nil
 }
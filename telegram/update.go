@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	tb "gopkg.in/telebot.v3"
+)
+
+// Update replaces the text of the first message r lists in each chat with
+// editMessageText. The inline keyboard is replaced by the Actions, if any.
+func (c *client) Update(ctx context.Context, r *receipt.Receipt, message string) error {
+	start := time.Now()
+	err := c.redactor.Error(c.update(ctx, r, message))
+	logger.Delivery(ctx, c.opt.Logger, "telegram", c.target(), start, err)
+	return err
+}
+
+func (c *client) update(ctx context.Context, r *receipt.Receipt, message string) error {
+	if c.opt.Token == "" {
+		return errors.New("missing token")
+	}
+	if r == nil || len(r.MessageIDs) == 0 {
+		return errors.New("missing message id")
+	}
+	if message == "" {
+		return errors.New("missing message")
+	}
+
+	bot, err := tb.NewBot(tb.Settings{Token: c.opt.Token, Offline: true})
+	if err != nil {
+		return err
+	}
+	opts := &tb.SendOptions{ParseMode: c.opt.ParseMode}
+	if len(c.opt.Actions) > 0 {
+		if opts.ReplyMarkup, err = inlineKeyboard(c.opt.Actions); err != nil {
+			return err
+		}
+	}
+
+	edited := make(map[int64]bool)
+	for _, ref := range r.MessageIDs {
+		i := strings.LastIndexByte(ref, ':')
+		if i < 0 {
+			return errors.New("invalid message id " + ref)
+		}
+		chatID, err := strconv.ParseInt(ref[:i], 10, 64)
+		if err != nil {
+			return errors.New("invalid message id " + ref)
+		}
+		// the other messages of a chat are the parts of a long message
+		if edited[chatID] {
+			continue
+		}
+		edited[chatID] = true
+
+		_, span := tracing.Start(ctx, "telegram editMessageText",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attribute.Int64("telegram.chat_id", chatID)),
+		)
+		_, err = bot.Edit(tb.StoredMessage{ChatID: chatID, MessageID: ref[i+1:]}, message, opts)
+		tracing.End(span, err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"math"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/markdown"
+)
+
+// Templates holds named message templates. A template may have a variant per
+// platform next to its default, so the same event can be worded differently
+// for Slack, Telegram or email.
+//
+// Templates are added with Add and AddHTML, or loaded from files named
+// "<name>.tmpl" for the default and "<name>.<platform>.tmpl" for a variant,
+// e.g. "disk_full.slack.tmpl". Files ending in ".html" or ".html.tmpl" are
+// parsed with html/template, which is what email variants should use.
+// A template may define a "subject" template, it becomes the message subject.
+//
+// Besides the text/template builtins, templates can use:
+//
+//	humanizeDuration  a time.Duration or seconds as "1h 5m"
+//	truncate N        the first N characters, ending with "…" when cut
+//	escape            escapes text for the platform being rendered
+//	formatAmount N    a number with thousands separators and N decimals
+//	mention           a Slack mention of an email address, "@handle" or
+//	                  "#channel", the target as is on other platforms
+type Templates struct {
+	mu   sync.RWMutex
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewTemplates() *Templates {
+	return &Templates{
+		text: make(map[string]*texttemplate.Template),
+		html: make(map[string]*htmltemplate.Template),
+	}
+}
+
+// WithTemplates renders messages that name a template with t.
+func WithTemplates(t *Templates) Option {
+	return func(n *Notify) {
+		n.templates = t
+	}
+}
+
+// Add parses text as the variant of template name for platform, or as its
+// default when platform is empty.
+func (t *Templates) Add(name string, platform Platform, text string) error {
+	key := templateKey(name, platform)
+	tmpl, err := texttemplate.New(key).Funcs(templateFuncs(platform, "", false)).Parse(text)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.text[key] = tmpl
+	delete(t.html, key)
+	return nil
+}
+
+// AddHTML is like Add, but parses text with html/template.
+func (t *Templates) AddHTML(name string, platform Platform, text string) error {
+	key := templateKey(name, platform)
+	tmpl, err := htmltemplate.New(key).Funcs(htmltemplate.FuncMap(templateFuncs(platform, "", true))).Parse(text)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.html[key] = tmpl
+	delete(t.text, key)
+	return nil
+}
+
+// ParseFS loads the template files matching patterns from fsys, such as an
+// embed.FS.
+func (t *Templates) ParseFS(fsys fs.FS, patterns ...string) error {
+	for _, pattern := range patterns {
+		files, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			b, err := fs.ReadFile(fsys, file)
+			if err != nil {
+				return err
+			}
+			if err := t.addFile(file, string(b)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ParseGlob loads the template files matching pattern from disk.
+func (t *Templates) ParseGlob(pattern string) error {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err := t.addFile(file, string(b)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Templates) addFile(file, text string) error {
+	base := path.Base(filepath.ToSlash(file))
+	base = strings.TrimSuffix(base, ".tmpl")
+	isHTML := strings.HasSuffix(base, ".html")
+	base = strings.TrimSuffix(base, ".html")
+
+	name, platform := base, Platform("")
+	if i := strings.LastIndex(base, "."); i > 0 {
+		if p, ok := platformByName[base[i+1:]]; ok {
+			name, platform = base[:i], p
+		}
+	}
+
+	var err error
+	if isHTML {
+		err = t.AddHTML(name, platform, text)
+	} else {
+		err = t.Add(name, platform, text)
+	}
+	if err != nil {
+		return fmt.Errorf("template %s: %w", file, err)
+	}
+	return nil
+}
+
+var platformByName = map[string]Platform{}
+
+func init() {
+	for _, p := range []Platform{
+		PlatformSlack, PlatformPushover, PlatformPagerduty, PlatformDiscord, PlatformTelegram,
+		PlatformDingTalk, PlatformEmail, PlatformSes, PlatformLark, PlatformArgus,
+	} {
+		platformByName[strings.ToLower(string(p))] = p
+	}
+}
+
+func templateKey(name string, platform Platform) string {
+	if platform == "" {
+		return name
+	}
+	return name + "." + strings.ToLower(string(platform))
+}
+
+// Render executes the variant of template name for platform, falling back
+// to its default, and returns the subject and text.
+func (t *Templates) Render(name string, platform Platform, data interface{}) (subject, text string, err error) {
+	return t.render(name, platform, "", data)
+}
+
+// render is Render for a message Telegram parses in parseMode, which
+// escape follows.
+func (t *Templates) render(name string, platform Platform, parseMode string, data interface{}) (subject, text string, err error) {
+	keys := []string{templateKey(name, platform)}
+	if platform == PlatformSes {
+		// both email platforms share the email variant
+		keys = append(keys, templateKey(name, PlatformEmail))
+	}
+	keys = append(keys, name)
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, key := range keys {
+		if tmpl, ok := t.text[key]; ok {
+			// executing a clone lets the escape function follow the platform
+			// being rendered when the default template is used
+			clone, err := tmpl.Clone()
+			if err != nil {
+				return "", "", err
+			}
+			clone.Funcs(templateFuncs(platform, parseMode, false))
+			return renderTemplate(clone, clone.Lookup("subject"), data)
+		}
+		if tmpl, ok := t.html[key]; ok {
+			// html templates cannot be cloned once executed, so the parsed
+			// one is never executed itself
+			clone, err := tmpl.Clone()
+			if err != nil {
+				return "", "", err
+			}
+			subject, text, err := renderTemplate(clone, clone.Lookup("subject"), data)
+			// the subject is a header, not html
+			return html.UnescapeString(subject), text, err
+		}
+	}
+	return "", "", fmt.Errorf("template %s not found", name)
+}
+
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+func renderTemplate(body, subject executor, data interface{}) (string, string, error) {
+	var buf bytes.Buffer
+	var subjectText string
+	if !isNilExecutor(subject) {
+		if err := subject.Execute(&buf, data); err != nil {
+			return "", "", err
+		}
+		subjectText = strings.TrimSpace(buf.String())
+		buf.Reset()
+	}
+	if err := body.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	return subjectText, strings.TrimSpace(buf.String()), nil
+}
+
+func isNilExecutor(e executor) bool {
+	switch t := e.(type) {
+	case *texttemplate.Template:
+		return t == nil
+	case *htmltemplate.Template:
+		return t == nil
+	}
+	return e == nil
+}
+
+func templateFuncs(platform Platform, parseMode string, isHTML bool) texttemplate.FuncMap {
+	escape := func(s string) string { return escapeFor(platform, parseMode, s) }
+	if isHTML {
+		// html/template escapes by itself
+		escape = func(s string) string { return s }
+	}
+	return texttemplate.FuncMap{
+		"humanizeDuration": humanizeDuration,
+		"truncate":         truncate,
+		"escape":           escape,
+		"formatAmount":     formatAmount,
+		"mention":          func(target string) string { return mentionFor(platform, target) },
+	}
+}
+
+// mentionFor leaves Slack mentions for the slack package, which looks them
+// up when sending.
+func mentionFor(platform Platform, target string) string {
+	if platform == PlatformSlack {
+		return "{{mention " + strconv.Quote(target) + "}}"
+	}
+	return target
+}
+
+// escapeFor escapes the characters the platform would otherwise interpret.
+// Telegram interprets them only in the parse mode of formatted messages.
+func escapeFor(platform Platform, parseMode, s string) string {
+	switch platform {
+	case PlatformTelegram:
+		switch parseMode {
+		case telegramMarkdown:
+			return markdown.EscapeTelegram(s)
+		case telegramHTML:
+			return html.EscapeString(s)
+		}
+		return s
+	case PlatformSlack:
+		return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	case PlatformDiscord:
+		return strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`).Replace(s)
+	case PlatformEmail, PlatformSes:
+		return html.EscapeString(s)
+	default:
+		return s
+	}
+}
+
+func humanizeDuration(v interface{}) (string, error) {
+	var d time.Duration
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	case int:
+		d = time.Duration(v) * time.Second
+	case int64:
+		d = time.Duration(v) * time.Second
+	case float64:
+		d = time.Duration(v * float64(time.Second))
+	default:
+		return "", fmt.Errorf("humanizeDuration: unsupported type %T", v)
+	}
+
+	if d < time.Second {
+		return d.String(), nil
+	}
+	d = d.Round(time.Second)
+	units := []struct {
+		suffix string
+		size   time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+	var parts []string
+	for _, u := range units {
+		if d >= u.size {
+			parts = append(parts, strconv.FormatInt(int64(d/u.size), 10)+u.suffix)
+			d %= u.size
+		}
+		// two units are precise enough for a notification
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return strings.Join(parts, " "), nil
+}
+
+func truncate(n int, s string) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n < 1 {
+		return ""
+	}
+	return string(r[:n-1]) + "…"
+}
+
+func formatAmount(decimals int, v interface{}) (string, error) {
+	var f float64
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case float32:
+		f = float64(v)
+	case int:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case string:
+		var err error
+		if f, err = strconv.ParseFloat(v, 64); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("formatAmount: unsupported type %T", v)
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", errors.New("formatAmount: not a finite number")
+	}
+
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	if f < 0 {
+		b.WriteByte('-')
+	}
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(frac)
+	return b.String(), nil
+}
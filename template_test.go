@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/discord"
+	"github.com/ChainbotAI/go-notify/slack"
+)
+
+func TestTemplates_Render(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/disk_full.tmpl":       {Data: []byte(`{{.Host}} disk is {{.Used}}% full for {{humanizeDuration .Since}}`)},
+		"templates/disk_full.slack.tmpl": {Data: []byte(`:warning: *{{escape .Host}}* disk is {{.Used}}% full`)},
+		"templates/disk_full.email.html.tmpl": {Data: []byte(
+			`{{define "subject"}}Disk full on {{.Host}}{{end}}<p>{{.Host}} is at {{.Used}}%</p>`)},
+	}
+	templates := NewTemplates()
+	if err := templates.ParseFS(fsys, "templates/*"); err != nil {
+		t.Fatal(err)
+	}
+
+	data := map[string]interface{}{
+		"Host":  "db<1>",
+		"Used":  97,
+		"Since": 3*time.Hour + 25*time.Minute + 10*time.Second,
+	}
+	tests := []struct {
+		platform    Platform
+		wantSubject string
+		wantText    string
+	}{
+		{PlatformSlack, "", ":warning: *db&lt;1&gt;* disk is 97% full"},
+		{PlatformTelegram, "", "db<1> disk is 97% full for 3h 25m"},
+		{PlatformSes, "Disk full on db<1>", "<p>db&lt;1&gt; is at 97%</p>"},
+	}
+	for _, tt := range tests {
+		subject, text, err := templates.Render("disk_full", tt.platform, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subject != tt.wantSubject || text != tt.wantText {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.platform, subject, text, tt.wantSubject, tt.wantText)
+		}
+	}
+}
+
+func TestTemplates_TelegramEscape(t *testing.T) {
+	templates := NewTemplates()
+	if err := templates.Add("down", PlatformTelegram, "{{escape .}} is down"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		parseMode string
+		want      string
+	}{
+		{"", "api-1.<prod> is down"},
+		{"MarkdownV2", `api\-1\.<prod\> is down`},
+		{"HTML", "api-1.&lt;prod&gt; is down"},
+	}
+	for _, tt := range tests {
+		_, text, err := templates.render("down", PlatformTelegram, tt.parseMode, "api-1.<prod>")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.parseMode, text, tt.want)
+		}
+	}
+
+	// markdown messages are parsed before they are rendered to MarkdownV2,
+	// which must keep the escaped text as is
+	n := NewNotify(&Config{Platform: PlatformTelegram}, WithTemplates(templates))
+	m, err := n.applyTemplate(&Message{Template: "down", Format: FormatMarkdown, Data: "api_1.<prod>"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.render(PlatformTelegram); got != `api\_1\.<prod\> is down` {
+		t.Errorf("rendered %q", got)
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		decimals int
+		v        interface{}
+		want     string
+	}{
+		{2, 1234567.891, "1,234,567.89"},
+		{0, -1000, "-1,000"},
+		{4, "0.5", "0.5000"},
+	}
+	for _, tt := range tests {
+		got, err := formatAmount(tt.decimals, tt.v)
+		if err != nil || got != tt.want {
+			t.Errorf("formatAmount(%d, %v) = %q, %v, want %q", tt.decimals, tt.v, got, err, tt.want)
+		}
+	}
+}
+
+func TestTemplates_SendToSeveralPlatforms(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Text    string `json:"text"`
+			Content string `json:"content"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		if strings.HasPrefix(r.URL.Path, "/slack") {
+			got["slack"] = body.Text
+			w.Write([]byte("ok"))
+			return
+		}
+		got["discord"] = body.Content
+		w.Write([]byte(`{"id":"1","channel_id":"2"}`))
+	}))
+	defer srv.Close()
+	defer func(u string) { slack.WebhookPrefix = u }(slack.WebhookPrefix)
+	defer func(u string) { discord.BotApiURL = u }(discord.BotApiURL)
+	slack.WebhookPrefix = srv.URL + "/slack/"
+	discord.BotApiURL = srv.URL + "/discord/"
+
+	templates := NewTemplates()
+	templates.Add("disk_full", "", "{{.Host}} disk is full")
+	templates.Add("disk_full", PlatformSlack, ":warning: {{.Host}} disk is full")
+	notifies := []*Notify{
+		NewNotify(&Config{Platform: PlatformSlack, Token: srv.URL + "/slack/services/T0/B0/secret"}, WithTemplates(templates)),
+		NewNotify(&Config{Platform: PlatformDiscord, Token: "token", Channel: "123", ChannelType: NotifyChannelTypeBot}, WithTemplates(templates)),
+	}
+
+	msg := &Message{Template: "disk_full", Data: map[string]string{"Host": "db1"}}
+	var wg sync.WaitGroup
+	for _, n := range notifies {
+		wg.Add(1)
+		go func(n *Notify) {
+			defer wg.Done()
+			if err := n.SendMessage(context.Background(), msg); err != nil {
+				t.Error(err)
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if got["slack"] != ":warning: db1 disk is full" || got["discord"] != "db1 disk is full" {
+		t.Errorf("sent %q", got)
+	}
+	if msg.Text != "" || msg.ID != "" {
+		t.Errorf("the message was changed: %+v", msg)
+	}
+}
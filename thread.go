@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ChainbotAI/go-notify/logger"
+	"github.com/ChainbotAI/go-notify/receipt"
+)
+
+// Receipt identifies the messages a provider sent, see the receipt package.
+type Receipt = receipt.Receipt
+
+// DefaultThreadTTL is how long the in-memory ThreadStore remembers a thread.
+const DefaultThreadTTL = 7 * 24 * time.Hour
+
+// ThreadStore remembers the platform thread of each Message.ThreadKey so
+// follow-ups are posted as replies: a Slack ts, Telegram message ids, a
+// Discord thread or an email Message-ID. The default store lives in memory,
+// a shared one such as redis keeps threads across restarts and replicas.
+type ThreadStore interface {
+	// Load returns the thread stored for key, ok is false if there is none.
+	Load(ctx context.Context, key string) (thread string, ok bool, err error)
+	Store(ctx context.Context, key, thread string) error
+}
+
+// WithThreadStore keeps threads in s instead of memory.
+func WithThreadStore(s ThreadStore) Option {
+	return func(n *Notify) {
+		n.threads = s
+	}
+}
+
+// NewMemoryThreadStore returns a ThreadStore forgetting threads ttl after
+// they are stored.
+func NewMemoryThreadStore(ttl time.Duration) ThreadStore {
+	return &memoryThreadStore{ttl: ttl, threads: make(map[string]storedThread)}
+}
+
+type storedThread struct {
+	thread  string
+	expires time.Time
+}
+
+type memoryThreadStore struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	threads map[string]storedThread
+	swept   time.Time
+}
+
+func (s *memoryThreadStore) Load(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.threads[key]
+	if !ok || time.Now().After(t.expires) {
+		return "", false, nil
+	}
+	return t.thread, true, nil
+}
+
+func (s *memoryThreadStore) Store(ctx context.Context, key, thread string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// drop the expired threads now and then rather than on every store
+	if now.Sub(s.swept) > time.Minute {
+		for k, t := range s.threads {
+			if now.After(t.expires) {
+				delete(s.threads, k)
+			}
+		}
+		s.swept = now
+	}
+	s.threads[key] = storedThread{thread: thread, expires: now.Add(s.ttl)}
+	return nil
+}
+
+// threadKey is where the thread of m is stored, threads are per platform
+// and target.
+func (c *Config) threadKey(m *Message) string {
+	return string(c.Platform) + ":" + c.targetHash() + ":" + m.ThreadKey
+}
+
+// loadThread returns the thread follow-ups of m reply to, "" when m has no
+// ThreadKey or starts a thread.
+func (n *Notify) loadThread(ctx context.Context, cfg *Config, m *Message) (string, error) {
+	if m.ThreadKey == "" || n.threads == nil {
+		return "", nil
+	}
+	thread, _, err := n.threads.Load(ctx, cfg.threadKey(m))
+	return thread, err
+}
+
+// storeThread remembers the thread started by r. The message is already
+// delivered so failures are logged rather than returned.
+func (n *Notify) storeThread(ctx context.Context, cfg *Config, m *Message, r *Receipt) {
+	if m.ThreadKey == "" || n.threads == nil || r == nil || r.ThreadID == "" {
+		return
+	}
+	if err := n.threads.Store(ctx, cfg.threadKey(m), r.ThreadID); err != nil {
+		logger.Default(cfg.Logger).Warn("notify thread not stored",
+			logger.Platform(string(cfg.Platform)),
+			logger.Err(err),
+		)
+	}
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryThreadStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryThreadStore(time.Hour)
+	if _, ok, _ := s.Load(ctx, "a"); ok {
+		t.Fatal("empty store returned a thread")
+	}
+	s.Store(ctx, "a", "1700000000.000100")
+	if thread, ok, _ := s.Load(ctx, "a"); !ok || thread != "1700000000.000100" {
+		t.Errorf("Load = %q, %v", thread, ok)
+	}
+
+	expired := NewMemoryThreadStore(-time.Second)
+	expired.Store(ctx, "a", "1")
+	if _, ok, _ := expired.Load(ctx, "a"); ok {
+		t.Error("expired thread returned")
+	}
+}
+
+func TestThreadPerTarget(t *testing.T) {
+	ctx := context.Background()
+	n := NewNotify(&Config{Platform: PlatformSlack, Channel: "C1"})
+	m := &Message{ThreadKey: "disk-full"}
+	n.storeThread(ctx, n.config, m, &Receipt{ThreadID: "1.1"})
+
+	if thread, _ := n.loadThread(ctx, n.config, m); thread != "1.1" {
+		t.Errorf("thread %q, want 1.1", thread)
+	}
+	other := &Config{Platform: PlatformSlack, Channel: "C2"}
+	if thread, _ := n.loadThread(ctx, other, m); thread != "" {
+		t.Errorf("thread %q leaked to another channel", thread)
+	}
+	if thread, _ := n.loadThread(ctx, n.config, &Message{}); thread != "" {
+		t.Errorf("message without a key got thread %q", thread)
+	}
+}
@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ChainbotAI/go-notify/discord"
+	"github.com/ChainbotAI/go-notify/internal/tracing"
+	"github.com/ChainbotAI/go-notify/pagerduty"
+	"github.com/ChainbotAI/go-notify/redact"
+	"github.com/ChainbotAI/go-notify/slack"
+	"github.com/ChainbotAI/go-notify/telegram"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ResolvedSubject heads the messages edited by Resolve.
+const ResolvedSubject = "✅ RESOLVED"
+
+// Update replaces the message r identifies with msg: Slack, Telegram and
+// Discord edit it in place, PagerDuty adds msg to the alert as a trigger
+// with the same dedup_key. Other platforms can not update messages.
+//
+// A message that was sent in parts is edited in its first part, in every
+// chat on Telegram, and msg is truncated to fit in it; the other parts are
+// left as they are.
+func (n *Notify) Update(ctx context.Context, r *Receipt, msg *Message) error {
+	return n.edit(ctx, "notify.Update", r, func(ctx context.Context, cfg *Config) error {
+		return n.update(ctx, cfg, r, msg)
+	})
+}
+
+// Resolve marks the message r identifies as resolved: PagerDuty alerts are
+// resolved and chat messages are edited to start with ResolvedSubject.
+func (n *Notify) Resolve(ctx context.Context, r *Receipt) error {
+	return n.edit(ctx, "notify.Resolve", r, func(ctx context.Context, cfg *Config) error {
+		if cfg.Platform == PlatformPagerduty {
+			return pagerduty.New(pagerdutyOptions(cfg, &delivery{})).Resolve(ctx, r)
+		}
+		return n.update(ctx, cfg, r, &Message{
+			Subject: ResolvedSubject,
+			Text:    r.Text,
+			Format:  Format(r.Format),
+		})
+	})
+}
+
+// edit runs fn with the resolved config in a span named name.
+func (n *Notify) edit(ctx context.Context, name string, r *Receipt, fn func(context.Context, *Config) error) error {
+	ctx, span := tracing.Start(ctx, name, trace.WithAttributes(
+		attribute.String("notify.platform", string(n.config.Platform)),
+	))
+	cfg, err := n.resolveConfig(ctx)
+	if err == nil {
+		switch {
+		case r == nil || len(r.MessageIDs) == 0:
+			err = errors.New("missing receipt")
+		case r.Platform != string(cfg.Platform):
+			err = fmt.Errorf("receipt of %s can not be used on %s", r.Platform, cfg.Platform)
+		default:
+			err = redact.New(cfg.secrets()...).Error(fn(ctx, cfg))
+		}
+	}
+	tracing.End(span, err)
+	return err
+}
+
+func (n *Notify) update(ctx context.Context, cfg *Config, r *Receipt, m *Message) error {
+	m, err := n.applyTemplate(m)
+	if err != nil {
+		return err
+	}
+	style, err := n.severityStyle(m)
+	if err != nil {
+		return err
+	}
+	text := cfg.styleText(style, m.render(cfg.Platform))
+	// an edit can not be split, only the first part is replaced
+	if limit := cfg.messageLimit(m); limit > 0 && textLength(cfg.Platform, text) > limit {
+		text = truncateText(cfg.Platform, m.Format, text, limit)
+	}
+	d := &delivery{text: text, actions: m.Actions, style: style}
+
+	switch cfg.Platform {
+	case PlatformSlack:
+		return slack.New(slackOptions(cfg, d)).Update(ctx, r, d.text)
+	case PlatformDiscord:
+		return discord.New(discordOptions(cfg, d)).Update(ctx, r, d.text)
+	case PlatformTelegram:
+		app := telegram.New(telegramOptions(cfg, m, d))
+		if app == nil {
+			return errors.New("create telegram client failed")
+		}
+		return app.Update(ctx, r, d.text)
+	case PlatformPagerduty:
+		options := pagerdutyOptions(cfg, d)
+		options.DedupKey = r.MessageID()
+		_, err := pagerduty.New(options).SendWithReceipt(ctx, d.text)
+		return err
+	default:
+		return fmt.Errorf("%s does not support updating messages", cfg.Platform)
+	}
+}
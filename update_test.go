@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ChainbotAI/go-notify/slack"
+)
+
+func TestResolveSlack(t *testing.T) {
+	var form map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat.update" {
+			t.Errorf("called %s", r.URL.Path)
+		}
+		r.ParseForm()
+		form = map[string]string{}
+		for k := range r.PostForm {
+			form[k] = r.PostForm.Get(k)
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	defer func(u string) { slack.ApiBaseURL = u }(slack.ApiBaseURL)
+	slack.ApiBaseURL = srv.URL + "/"
+
+	n := NewNotify(&Config{Platform: PlatformSlack, Token: "xoxb-1", Channel: "alerts"})
+	r := &Receipt{Platform: string(PlatformSlack), Channel: "C1", MessageIDs: []string{"1700000000.000100"}, Text: "disk full"}
+	if err := n.Resolve(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"channel":     "C1",
+		"ts":          "1700000000.000100",
+		"text":        ResolvedSubject + "\ndisk full",
+		"blocks":      "[]",
+		"attachments": "[]",
+	}
+	for k, v := range want {
+		if form[k] != v {
+			t.Errorf("%s = %q, want %q", k, form[k], v)
+		}
+	}
+
+	r.Platform = PlatformDiscord
+	if err := n.Resolve(context.Background(), r); err == nil {
+		t.Error("a Discord receipt was used on Slack")
+	}
+}
+
+func TestUpdateSplitMessage(t *testing.T) {
+	var calls []map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		calls = append(calls, map[string]string{"ts": r.PostForm.Get("ts"), "text": r.PostForm.Get("text")})
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+	defer func(u string) { slack.ApiBaseURL = u }(slack.ApiBaseURL)
+	slack.ApiBaseURL = srv.URL + "/"
+
+	n := NewNotify(&Config{Platform: PlatformSlack, Token: "xoxb-1", Channel: "alerts", MaxLength: 100})
+	r := &Receipt{Platform: string(PlatformSlack), Channel: "C1", MessageIDs: []string{"1.1", "1.2", "1.3"}}
+	text := strings.Repeat("disk full on db1 ", 20)
+	if err := n.Update(context.Background(), r, &Message{Text: text}); err != nil {
+		t.Fatal(err)
+	}
+	// the first part gets the text, truncated to the limit
+	if len(calls) != 1 || calls[0]["ts"] != "1.1" || utf8.RuneCountInString(calls[0]["text"]) > 100 || !strings.HasPrefix(calls[0]["text"], "disk full on db1") {
+		t.Errorf("edits %v", calls)
+	}
+}